@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/terraform-exec/tfexec"
+	tfjson "github.com/hashicorp/terraform-json"
+
+	"github.com/busser/tfautomv/internal/format"
+	"github.com/busser/tfautomv/internal/pipeline"
+	"github.com/busser/tfautomv/internal/terraform"
+	"github.com/busser/tfautomv/internal/tfautomv"
+)
+
+// runCrossState finds resources that moved from one working directory's state
+// to another's. It plans each working directory and compares resources planned
+// for destruction in any of them with resources planned for creation in any of
+// them.
+func runCrossState(ctx context.Context, find pipeline.Options) error {
+	switch {
+	case len(workdirs) < 2:
+		return errors.New("the -dir flag must be repeated to compare at least two working directories")
+	case collapseMoves:
+		return errors.New("the -dir and -collapse flags are mutually exclusive")
+	case verify:
+		return errors.New("the -dir and -verify flags are mutually exclusive")
+	case outputPlacement != "file":
+		return errors.New("the -dir flag only supports writing moved blocks to -output-file")
+	case outputFormat == "tfmigrate":
+		return errors.New("the tfmigrate output format does not support moves between states")
+	}
+
+	tfs := make(map[string]*tfexec.Terraform, len(workdirs))
+	for _, dir := range workdirs {
+		tf, err := tfexec.NewTerraform(dir, terraformBin)
+		if err != nil {
+			return err
+		}
+		tfs[dir] = tf
+
+		// Moving a resource to another state requires removing it from one
+		// configuration and importing it in the other. Each working directory
+		// can use its own version of Terraform.
+		if outputFormat == "blocks" || outputFormat == "imports" {
+			v, _, err := tf.Version(ctx, false)
+			if err != nil {
+				return err
+			}
+			if !supports(v, "1.7") {
+				return fmt.Errorf("terraform version %s in %q does not support removed blocks, which are required to move resources between states", v.String(), dir)
+			}
+		}
+	}
+
+	find.Analysis.StateProviders = make(map[string]map[string]string, len(workdirs))
+	for _, dir := range workdirs {
+		tf := tfs[dir]
+
+		logln(fmt.Sprintf("Running \"terraform init\" in %q...", dir))
+		if err := tf.Init(ctx); err != nil {
+			return err
+		}
+
+		providers, err := stateProviders(ctx, tf)
+		if err != nil {
+			return err
+		}
+		find.Analysis.StateProviders[dir] = providers
+	}
+
+	// Moves between states cannot be written as moved blocks, so there is a
+	// single pass.
+	find.Plan = func(int) (map[string]*tfjson.Plan, error) {
+		plans := make(map[string]*tfjson.Plan, len(workdirs))
+		for _, dir := range workdirs {
+			logln(fmt.Sprintf("Running \"terraform plan\" in %q...", dir))
+			plan, err := terraformPlan(ctx, tfs[dir])
+			if err != nil {
+				return nil, err
+			}
+			plans[dir] = plan
+		}
+		return plans, nil
+	}
+
+	res, err := pipeline.FindMoves(find)
+	if err != nil {
+		return err
+	}
+
+	// Moving resources between states requires import blocks either way.
+	writeBlocks := func(res *pipeline.Result) error {
+		return writeCrossStateBlocks(res.Analysis, res.Moves, res.Plans)
+	}
+
+	return output(res, map[string]func(*pipeline.Result) error{
+		"blocks":  writeBlocks,
+		"imports": writeBlocks,
+		"commands": func(res *pipeline.Result) error {
+			terraform.WriteCrossStateShellCommands(res.Moves, terraformBin, os.Stdout)
+			fmt.Fprint(os.Stderr, format.Done(fmt.Sprintf("Wrote commands for %d moves to standard output.", len(res.Moves))))
+			return nil
+		},
+	})
+}
+
+// writeCrossStateBlocks writes moved blocks for moves within a state, and
+// pairs of removed and import blocks for moves between states. Blocks are
+// written to -output-file in each working directory.
+func writeCrossStateBlocks(analysis *tfautomv.Analysis, moves []terraform.Move, plans map[string]*tfjson.Plan) error {
+	var local, cross []terraform.Move
+	for _, m := range moves {
+		if m.CrossState() {
+			cross = append(cross, m)
+		} else {
+			local = append(local, m)
+		}
+	}
+
+	inState := make(map[string][]string, len(plans))
+	created := make(map[string][]string, len(plans))
+	for dir, plan := range plans {
+		inState[dir] = terraform.StateAddresses(plan.PriorState)
+		created[dir] = terraform.CreatedAddresses(plan)
+	}
+	removals, err := terraform.RemovalsFromMoves(cross, inState, created)
+	if err != nil {
+		return fmt.Errorf("%w; use -output=commands instead", err)
+	}
+	imports, err := tfautomv.ImportsFromMoves(analysis, cross)
+	if err != nil {
+		return err
+	}
+	for _, w := range terraform.ImportIDWarnings(imports) {
+		fmt.Fprint(os.Stderr, format.Warning(w))
+	}
+
+	for _, dir := range workdirs {
+		path := filepath.Join(dir, outputFile)
+
+		var dirMoves []terraform.Move
+		for _, m := range local {
+			if m.FromWorkdir == dir {
+				dirMoves = append(dirMoves, m)
+			}
+		}
+		var dirRemovals []terraform.Removal
+		for _, r := range removals {
+			if r.Workdir == dir {
+				dirRemovals = append(dirRemovals, r)
+			}
+		}
+		var dirImports []terraform.Import
+		for _, i := range imports {
+			if i.Workdir == dir {
+				dirImports = append(dirImports, i)
+			}
+		}
+
+		if len(dirMoves) == 0 && len(dirRemovals) == 0 && len(dirImports) == 0 {
+			continue
+		}
+
+		if err := terraform.AppendMovesToFile(dirMoves, path); err != nil {
+			return err
+		}
+		if err := terraform.AppendRemovalsToFile(dirRemovals, path); err != nil {
+			return err
+		}
+		if err := terraform.AppendImportsToFile(dirImports, path); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprint(os.Stderr, format.Done(fmt.Sprintf("Added %d moved, %d removed, and %d import blocks to %q in each working directory.", len(local), len(removals), len(imports), outputFile)))
+
+	return nil
+}
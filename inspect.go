@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/busser/tfautomv/internal/format"
+	"github.com/busser/tfautomv/internal/tfautomv"
+)
+
+// inspector returns a function that reports on the analysis of each pass, and
+// lets the user resolve ambiguities when the -interactive flag is set.
+// Ambiguities the user skips are remembered across passes.
+func inspector() func(*tfautomv.Analysis, int, []tfautomv.Pairing) error {
+	skipped := make(map[ambiguityKey]bool)
+
+	return func(analysis *tfautomv.Analysis, pass int, ignoredPairings []tfautomv.Pairing) error {
+		if showAnalysis {
+			fmt.Fprint(os.Stderr, format.Analysis(analysis))
+		}
+		if pass == 1 {
+			// Later passes ignore the pairings of moves written during
+			// earlier passes, so only the first pass can reveal typos.
+			warnIgnoredPairings(ignoredPairings)
+			warnIndexShifts(analysis)
+		}
+		if interactive {
+			return resolveAmbiguities(analysis, skipped)
+		}
+		return nil
+	}
+}
+
+// stdin reads the user's choices in interactive mode. It is shared by all
+// prompts so that no buffered input is lost between them.
+var stdin = bufio.NewReader(os.Stdin)
+
+// resolveAmbiguities asks the user which resource to pair with each resource
+// that matches several others. Each choice can resolve other ambiguities, so
+// they are recomputed after every choice. Resources the user skips are left
+// as they are, and recorded in skipped so that later passes do not ask about
+// them again.
+func resolveAmbiguities(analysis *tfautomv.Analysis, skipped map[ambiguityKey]bool) error {
+	for {
+		var amb *tfautomv.Ambiguity
+		for _, a := range analysis.Ambiguities() {
+			if !skipped[keyOf(a)] {
+				a := a
+				amb = &a
+				break
+			}
+		}
+		if amb == nil {
+			return nil
+		}
+
+		fmt.Fprint(os.Stderr, format.Ambiguity(*amb))
+
+		choice, err := promptChoice(len(amb.Matches))
+		if err != nil {
+			return err
+		}
+		if choice == 0 {
+			skipped[keyOf(*amb)] = true
+			continue
+		}
+
+		comp := amb.Matches[choice-1]
+		if err := analysis.Pin(comp.Created, comp.Destroyed); err != nil {
+			return err
+		}
+	}
+}
+
+// ambiguityKey identifies an ambiguous resource across passes, since each
+// pass analyses a new plan with new resources.
+type ambiguityKey struct {
+	workdir string
+	address string
+	created bool
+}
+
+func keyOf(a tfautomv.Ambiguity) ambiguityKey {
+	return ambiguityKey{workdir: a.Resource.Workdir, address: a.Resource.Address, created: a.IsCreated()}
+}
+
+// promptChoice asks the user to choose a number between 1 and n. It returns 0
+// if the user chooses to skip.
+func promptChoice(n int) (int, error) {
+	for {
+		fmt.Fprintf(os.Stderr, "Choose a match (1-%d), or press Enter to skip: ", n)
+
+		line, err := stdin.ReadString('\n')
+		if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+			return 0, fmt.Errorf("could not read choice: %w", err)
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return 0, nil
+		}
+
+		i, err := strconv.Atoi(line)
+		if err == nil && i >= 1 && i <= n {
+			return i, nil
+		}
+
+		fmt.Fprintf(os.Stderr, "Invalid choice %q.\n", line)
+	}
+}
+
+// warnIgnoredPairings warns about pairings that match no resource planned for
+// creation or destruction. Those may describe moves that were already made,
+// but may also be typos.
+func warnIgnoredPairings(ignored []tfautomv.Pairing) {
+	if len(ignored) == 0 {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("These pairings were ignored, because Terraform does not plan to create or destroy their resources:\n")
+	for _, p := range ignored {
+		if p.Never {
+			fmt.Fprintf(&b, "\n  never %s -> %s", p.From, p.To)
+		} else {
+			fmt.Fprintf(&b, "\n  %s -> %s", p.From, p.To)
+		}
+	}
+	b.WriteString("\n\nIf the moves were already made, you can ignore this warning. Otherwise, check the pairings file for typos.")
+	fmt.Fprint(os.Stderr, format.Warning(b.String()))
+}
+
+// warnIndexShifts warns the user about resources whose instances shifted to
+// other indices. tfautomv cannot move them, since each index is still in use.
+func warnIndexShifts(analysis *tfautomv.Analysis) {
+	shifts := analysis.IndexShifts()
+	if len(shifts) == 0 {
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("The instances of these resources shifted to other indices, so Terraform plans to replace them:\n")
+	for _, addr := range shifts {
+		fmt.Fprintf(&b, "\n  %s", addr)
+	}
+	b.WriteString("\n\nMoved blocks cannot fix this, because each index is still in use. Consider using for_each instead of count, so that each instance has a stable key. tfautomv can then move the existing instances to their new keys.")
+	fmt.Fprint(os.Stderr, format.Warning(b.String()))
+}
@@ -0,0 +1,125 @@
+package assignment
+
+import "math"
+
+// Maximize solves the assignment problem with the Hungarian algorithm.
+//
+// weights[i][j] is the gain of assigning row i to column j. All rows must have
+// the same number of columns. A negative weight means row i must never be
+// assigned to column j.
+//
+// Maximize returns, for each row, the index of the column it is assigned to,
+// or -1 if the row is not assigned. Each column is assigned to at most one row
+// and the sum of the weights of all assigned pairs is as high as possible.
+func Maximize(weights [][]float64) []int {
+	rows := len(weights)
+	if rows == 0 {
+		return nil
+	}
+	cols := len(weights[0])
+
+	assignment := make([]int, rows)
+	for i := range assignment {
+		assignment[i] = -1
+	}
+	if cols == 0 {
+		return assignment
+	}
+
+	// The algorithm below requires at least as many columns as rows. If that
+	// is not the case, we solve the transposed problem instead.
+	transposed := rows > cols
+	n, m := rows, cols
+	if transposed {
+		n, m = cols, rows
+	}
+
+	// The algorithm minimizes cost. Forbidden pairs have no gain, so choosing
+	// them is no better than leaving a row unassigned. We filter them out at
+	// the end.
+	cost := func(i, j int) float64 {
+		if transposed {
+			i, j = j, i
+		}
+		w := weights[i][j]
+		if w < 0 {
+			return 0
+		}
+		return -w
+	}
+
+	// This is the classic O(n²m) implementation with potentials. Indices are
+	// 1-based; row and column 0 are sentinels.
+	u := make([]float64, n+1)
+	v := make([]float64, m+1)
+	p := make([]int, m+1)   // p[j] is the row assigned to column j
+	way := make([]int, m+1) // way[j] is the previous column on the path to j
+
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		minv := make([]float64, m+1)
+		used := make([]bool, m+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+
+		for {
+			used[j0] = true
+			i0 := p[j0]
+			delta := math.Inf(1)
+			j1 := 0
+
+			for j := 1; j <= m; j++ {
+				if used[j] {
+					continue
+				}
+				cur := cost(i0-1, j-1) - u[i0] - v[j]
+				if cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+
+			for j := 0; j <= m; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+
+			j0 = j1
+			if p[j0] == 0 {
+				break
+			}
+		}
+
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+
+	for j := 1; j <= m; j++ {
+		if p[j] == 0 {
+			continue
+		}
+		row, col := p[j]-1, j-1
+		if transposed {
+			row, col = col, row
+		}
+		if weights[row][col] < 0 {
+			continue
+		}
+		assignment[row] = col
+	}
+
+	return assignment
+}
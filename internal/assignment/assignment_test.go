@@ -0,0 +1,156 @@
+package assignment
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/busser/tfautomv/internal/slices"
+)
+
+func TestMaximize(t *testing.T) {
+	tt := []struct {
+		name    string
+		weights [][]float64
+		want    []int
+	}{
+		{
+			name:    "empty",
+			weights: nil,
+			want:    nil,
+		},
+		{
+			name: "no columns",
+			weights: [][]float64{
+				{},
+				{},
+			},
+			want: []int{-1, -1},
+		},
+		{
+			name: "identity",
+			weights: [][]float64{
+				{1, 0, 0},
+				{0, 1, 0},
+				{0, 0, 1},
+			},
+			want: []int{0, 1, 2},
+		},
+		{
+			name: "greedy is not optimal",
+			weights: [][]float64{
+				{0.9, 0.8},
+				{0.8, 0.1},
+			},
+			want: []int{1, 0},
+		},
+		{
+			name: "more columns than rows",
+			weights: [][]float64{
+				{0.1, 0.5, 0.9},
+				{0.2, 0.9, 0.8},
+			},
+			want: []int{2, 1},
+		},
+		{
+			name: "more rows than columns",
+			weights: [][]float64{
+				{0.1, 0.5},
+				{0.9, 0.8},
+				{0.2, 0.9},
+			},
+			want: []int{-1, 0, 1},
+		},
+		{
+			name: "forbidden pairs",
+			weights: [][]float64{
+				{-1, 0.5},
+				{-1, -1},
+			},
+			want: []int{1, -1},
+		},
+		{
+			name: "all forbidden",
+			weights: [][]float64{
+				{-1, -1},
+				{-1, -1},
+			},
+			want: []int{-1, -1},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := Maximize(tc.weights)
+			if !slices.Equal(actual, tc.want) {
+				t.Errorf("Maximize() = %v, want %v", actual, tc.want)
+			}
+		})
+	}
+}
+
+func TestMaximizeIsOptimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+
+	for n := 0; n < 200; n++ {
+		rows, cols := 1+rng.Intn(5), 1+rng.Intn(5)
+		weights := make([][]float64, rows)
+		for i := range weights {
+			weights[i] = make([]float64, cols)
+			for j := range weights[i] {
+				weights[i][j] = float64(rng.Intn(10)) - 2
+			}
+		}
+
+		actual := Maximize(weights)
+
+		used := make(map[int]bool)
+		for i, j := range actual {
+			if j < 0 {
+				continue
+			}
+			if used[j] {
+				t.Fatalf("Maximize(%v) assigned column %d twice", weights, j)
+			}
+			used[j] = true
+			if weights[i][j] < 0 {
+				t.Fatalf("Maximize(%v) assigned forbidden pair (%d, %d)", weights, i, j)
+			}
+		}
+
+		if got, want := totalWeight(weights, actual), bestTotalWeight(weights, 0, make(map[int]bool)); got != want {
+			t.Errorf("Maximize(%v) has total weight %v, want %v", weights, got, want)
+		}
+	}
+}
+
+func totalWeight(weights [][]float64, assignment []int) float64 {
+	var total float64
+	for i, j := range assignment {
+		if j >= 0 {
+			total += weights[i][j]
+		}
+	}
+	return total
+}
+
+// bestTotalWeight finds the optimal total weight by trying every possible
+// assignment.
+func bestTotalWeight(weights [][]float64, row int, used map[int]bool) float64 {
+	if row == len(weights) {
+		return 0
+	}
+
+	best := bestTotalWeight(weights, row+1, used)
+	for j := range weights[row] {
+		if used[j] || weights[row][j] < 0 {
+			continue
+		}
+		used[j] = true
+		if w := weights[row][j] + bestTotalWeight(weights, row+1, used); w > best {
+			best = w
+		}
+		used[j] = false
+	}
+
+	return best
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/busser/tfautomv/internal/tfautomv/ignore"
+)
+
+// FileName is the name of tfautomv's configuration file.
+const FileName = ".tfautomv.hcl"
+
+// YAMLFileNames are the names of tfautomv's configuration file when it is
+// written in YAML rather than HCL.
+var YAMLFileNames = []string{".tfautomv.yaml", ".tfautomv.yml"}
+
+// Config holds the settings read from a configuration file. Fields that are
+// not set in the file have their zero value.
+type Config struct {
+	// Path to the file the configuration was read from.
+	Path string
+
+	// Rules to ignore certain differences between attributes.
+	Rules []ignore.Rule
+
+	// Output format of moves.
+	Output string
+
+	// Path to the file moved blocks are written to.
+	OutputFile string
+
+	// Terraform binary to use.
+	TerraformBin string
+}
+
+var schema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "ignore"},
+		{Name: "output"},
+		{Name: "output_file"},
+		{Name: "terraform_bin"},
+	},
+}
+
+// Find looks for a configuration file in dir and then in each of its parent
+// directories. It returns the path of the first file it finds, or an empty
+// string if there is none. Within a directory, the HCL file takes precedence
+// over the YAML ones.
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	names := append([]string{FileName}, YAMLFileNames...)
+
+	for {
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+
+			_, err := os.Stat(path)
+			if err == nil {
+				return path, nil
+			}
+			if !errors.Is(err, os.ErrNotExist) {
+				return "", err
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads the configuration file at path. Files with a ".yaml" or ".yml"
+// extension are read as YAML, others as HCL. Errors include the position in
+// the file of whatever is invalid.
+func Load(path string) (*Config, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		return parseYAML(src, path)
+	default:
+		return parse(src, path)
+	}
+}
+
+func parse(src []byte, filename string) (*Config, error) {
+	file, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	content, diags := file.Body.Content(schema)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	cfg := Config{
+		Path: filename,
+	}
+
+	if attr, ok := content.Attributes["ignore"]; ok {
+		rules, err := parseRules(attr)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Rules = rules
+	}
+
+	stringAttributes := map[string]*string{
+		"output":        &cfg.Output,
+		"output_file":   &cfg.OutputFile,
+		"terraform_bin": &cfg.TerraformBin,
+	}
+	for name, dst := range stringAttributes {
+		attr, ok := content.Attributes[name]
+		if !ok {
+			continue
+		}
+		s, err := stringValue(attr.Expr)
+		if err != nil {
+			return nil, err
+		}
+		*dst = s
+	}
+
+	return &cfg, nil
+}
+
+// parseRules parses each element of the ignore attribute as a rule. When the
+// attribute is written as a list, errors point to the invalid element.
+func parseRules(attr *hcl.Attribute) ([]ignore.Rule, error) {
+	tuple, ok := attr.Expr.(*hclsyntax.TupleConsExpr)
+	if !ok {
+		return nil, fmt.Errorf("%s: ignore must be a list of rules", attr.Expr.Range())
+	}
+
+	var rules []ignore.Rule
+	for _, expr := range tuple.Exprs {
+		raw, err := stringValue(expr)
+		if err != nil {
+			return nil, err
+		}
+
+		r, err := ignore.ParseRule(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid rule %q: %w", expr.Range(), raw, err)
+		}
+		rules = append(rules, r)
+	}
+
+	return rules, nil
+}
+
+func stringValue(expr hcl.Expression) (string, error) {
+	v, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		return "", diags
+	}
+
+	if v.IsNull() || !v.IsKnown() || v.Type() != cty.String {
+		return "", fmt.Errorf("%s: expected a string", expr.Range())
+	}
+
+	return v.AsString(), nil
+}
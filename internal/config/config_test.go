@@ -0,0 +1,212 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/busser/tfautomv/internal/tfautomv/ignore"
+)
+
+func TestFind(t *testing.T) {
+	want, err := filepath.Abs(filepath.Join("testdata", FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dir := range []string{
+		"testdata",
+		filepath.Join("testdata", "nested"),
+		filepath.Join("testdata", "nested", "deeper"),
+	} {
+		actual, err := Find(dir)
+		if err != nil {
+			t.Fatalf("Find(%q): unexpected error: %v", dir, err)
+		}
+		if actual != want {
+			t.Errorf("Find(%q) = %q, want %q", dir, actual, want)
+		}
+	}
+
+	wantYAML, err := filepath.Abs(filepath.Join("testdata", "yaml", ".tfautomv.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := Find(filepath.Join("testdata", "yaml"))
+	if err != nil {
+		t.Fatalf("Find(): unexpected error: %v", err)
+	}
+	if actual != wantYAML {
+		t.Errorf("Find() = %q, want %q", actual, wantYAML)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	for _, path := range []string{
+		filepath.Join("testdata", FileName),
+		filepath.Join("testdata", "yaml", ".tfautomv.yaml"),
+	} {
+		actual, err := Load(path)
+		if err != nil {
+			t.Fatalf("Load(%q): unexpected error: %v", path, err)
+		}
+
+		want := &Config{
+			Path: path,
+			Rules: []ignore.Rule{
+				ignore.MustParseRule("everything:random_pet:length"),
+				ignore.MustParseRule("prefix:google_storage_bucket_iam_member:bucket:b/"),
+			},
+			Output:       "commands",
+			OutputFile:   "refactoring.tf",
+			TerraformBin: "terragrunt",
+		}
+
+		if !reflect.DeepEqual(actual, want) {
+			t.Errorf("Load(%q) mismatch:\ngot: %#v\nwant: %#v", path, actual, want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tt := []struct {
+		name    string
+		src     string
+		want    *Config
+		wantErr string
+	}{
+		{
+			name: "empty",
+			src:  "",
+			want: &Config{Path: "test.hcl"},
+		},
+		{
+			name: "some settings",
+			src:  "output = \"blocks\"\n",
+			want: &Config{Path: "test.hcl", Output: "blocks"},
+		},
+		{
+			name:    "invalid rule",
+			src:     "ignore = [\n  \"everything:random_pet:length\",\n  \"doesnotexist:foo:bar\",\n]\n",
+			wantErr: "test.hcl:3,3-25: invalid rule \"doesnotexist:foo:bar\"",
+		},
+		{
+			name:    "rules not in a list",
+			src:     "ignore = \"everything:random_pet:length\"\n",
+			wantErr: "test.hcl:1,10-40: ignore must be a list of rules",
+		},
+		{
+			name:    "wrong type",
+			src:     "output = 123\n",
+			wantErr: "test.hcl:1,10-13: expected a string",
+		},
+		{
+			name:    "unknown setting",
+			src:     "foo = \"bar\"\n",
+			wantErr: "test.hcl:1,1-4: Unsupported argument",
+		},
+		{
+			name:    "syntax error",
+			src:     "output = \n",
+			wantErr: "test.hcl:1,10-2,1",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := parse([]byte(tc.src), "test.hcl")
+
+			if tc.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expected error, got none")
+				}
+				if !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("error %q should contain %q", err.Error(), tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(actual, tc.want) {
+				t.Errorf("parse() mismatch:\ngot: %#v\nwant: %#v", actual, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	tt := []struct {
+		name    string
+		src     string
+		want    *Config
+		wantErr string
+	}{
+		{
+			name: "empty",
+			src:  "",
+			want: &Config{Path: "test.yaml"},
+		},
+		{
+			name: "some settings",
+			src:  "output: blocks\n",
+			want: &Config{Path: "test.yaml", Output: "blocks"},
+		},
+		{
+			name:    "invalid rule",
+			src:     "ignore:\n  - everything:random_pet:length\n  - doesnotexist:foo:bar\n",
+			wantErr: "test.yaml:3,5: invalid rule \"doesnotexist:foo:bar\"",
+		},
+		{
+			name:    "rules not in a list",
+			src:     "ignore: everything:random_pet:length\n",
+			wantErr: "test.yaml:1,9: ignore must be a list of rules",
+		},
+		{
+			name:    "wrong type",
+			src:     "output: 123\n",
+			wantErr: "test.yaml:1,9: expected a string",
+		},
+		{
+			name:    "unknown setting",
+			src:     "foo: bar\n",
+			wantErr: "test.yaml:1,1: unsupported setting \"foo\"",
+		},
+		{
+			name:    "not a mapping",
+			src:     "- output\n",
+			wantErr: "test.yaml:1,1: expected a mapping of settings",
+		},
+		{
+			name:    "syntax error",
+			src:     "output: [\n",
+			wantErr: "test.yaml: yaml:",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := parseYAML([]byte(tc.src), "test.yaml")
+
+			if tc.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expected error, got none")
+				}
+				if !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("error %q should contain %q", err.Error(), tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(actual, tc.want) {
+				t.Errorf("parseYAML() mismatch:\ngot: %#v\nwant: %#v", actual, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+terraform_bin = "terragrunt"
+output        = "commands"
+output_file   = "refactoring.tf"
+
+ignore = [
+  "everything:random_pet:length",
+  "prefix:google_storage_bucket_iam_member:bucket:b/",
+]
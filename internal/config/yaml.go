@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/busser/tfautomv/internal/tfautomv/ignore"
+)
+
+// parseYAML parses a configuration file written in YAML. It supports the same
+// settings as the HCL file, with the same names.
+func parseYAML(src []byte, filename string) (*Config, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(src, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	cfg := Config{
+		Path: filename,
+	}
+
+	// An empty file has no content at all.
+	if len(doc.Content) == 0 {
+		return &cfg, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: expected a mapping of settings", yamlPos(filename, root))
+	}
+
+	stringSettings := map[string]*string{
+		"output":        &cfg.Output,
+		"output_file":   &cfg.OutputFile,
+		"terraform_bin": &cfg.TerraformBin,
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+
+		if key.Value == "ignore" {
+			rules, err := parseYAMLRules(value, filename)
+			if err != nil {
+				return nil, err
+			}
+			cfg.Rules = rules
+			continue
+		}
+
+		dst, ok := stringSettings[key.Value]
+		if !ok {
+			return nil, fmt.Errorf("%s: unsupported setting %q", yamlPos(filename, key), key.Value)
+		}
+		s, err := yamlString(value, filename)
+		if err != nil {
+			return nil, err
+		}
+		*dst = s
+	}
+
+	return &cfg, nil
+}
+
+// parseYAMLRules parses each element of the ignore setting as a rule. Errors
+// point to the invalid element.
+func parseYAMLRules(node *yaml.Node, filename string) ([]ignore.Rule, error) {
+	if node.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("%s: ignore must be a list of rules", yamlPos(filename, node))
+	}
+
+	var rules []ignore.Rule
+	for _, elem := range node.Content {
+		raw, err := yamlString(elem, filename)
+		if err != nil {
+			return nil, err
+		}
+
+		r, err := ignore.ParseRule(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid rule %q: %w", yamlPos(filename, elem), raw, err)
+		}
+		rules = append(rules, r)
+	}
+
+	return rules, nil
+}
+
+func yamlString(node *yaml.Node, filename string) (string, error) {
+	if node.Kind != yaml.ScalarNode || node.ShortTag() != "!!str" {
+		return "", fmt.Errorf("%s: expected a string", yamlPos(filename, node))
+	}
+	return node.Value, nil
+}
+
+// yamlPos describes the position of node in the file, like "file.yaml:3,5".
+func yamlPos(filename string, node *yaml.Node) string {
+	return fmt.Sprintf("%s:%d,%d", filename, node.Line, node.Column)
+}
@@ -35,6 +35,9 @@ func TestE2E(t *testing.T) {
 
 		skip       bool
 		skipReason string
+
+		// Output formats this test case does not apply to, and why.
+		skipOutputFormats map[string]string
 	}{
 		{
 			name:        "same attributes",
@@ -51,8 +54,18 @@ func TestE2E(t *testing.T) {
 			wantOutputInclude: []string{
 				colorEscapeSequence,
 			},
-			skip:       true,
-			skipReason: "tfautomv cannot yet solve this case",
+		},
+		{
+			name:        "multiple passes",
+			workdir:     filepath.Join("testdata", "multi-pass"),
+			wantChanges: 0,
+			wantOutputInclude: []string{
+				colorEscapeSequence,
+				"in 3 passes",
+			},
+			skipOutputFormats: map[string]string{
+				"commands": "tfautomv only runs multiple passes when writing moved blocks",
+			},
 		},
 		{
 			name:        "same type",
@@ -81,6 +94,22 @@ func TestE2E(t *testing.T) {
 				colorEscapeSequence,
 			},
 		},
+		{
+			name:        "count to for_each",
+			workdir:     filepath.Join("testdata", "count-to-for-each"),
+			wantChanges: 0,
+			wantOutputInclude: []string{
+				colorEscapeSequence,
+			},
+		},
+		{
+			name:        "index shift",
+			workdir:     filepath.Join("testdata", "index-shift"),
+			wantChanges: 3,
+			wantOutputInclude: []string{
+				"Consider using for_each instead of count",
+			},
+		},
 		{
 			name:    "no color",
 			workdir: filepath.Join("testdata", "same-attributes"),
@@ -138,6 +167,9 @@ func TestE2E(t *testing.T) {
 					if tc.skip {
 						t.Skip(tc.skipReason)
 					}
+					if reason, ok := tc.skipOutputFormats[outputFormat]; ok {
+						t.Skip(reason)
+					}
 
 					if outputFormat == "blocks" {
 						tf, err := tfexec.NewTerraform(originalWorkdir, terraformBin)
@@ -0,0 +1,17 @@
+locals {
+  prefixes = ["alpha", "bravo", "charlie"]
+}
+
+resource "random_pet" "this" {
+  count = length(local.prefixes)
+
+  prefix = local.prefixes[count.index]
+  length = 2
+}
+
+resource "random_pet" "that" {
+  count = length(local.prefixes)
+
+  prefix = local.prefixes[count.index]
+  length = 2
+}
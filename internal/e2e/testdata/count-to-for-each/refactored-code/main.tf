@@ -0,0 +1,17 @@
+locals {
+  prefixes = ["alpha", "bravo", "charlie"]
+}
+
+resource "random_pet" "this" {
+  for_each = toset(local.prefixes)
+
+  prefix = each.value
+  length = 2
+}
+
+resource "random_pet" "that" {
+  for_each = toset(local.prefixes)
+
+  prefix = each.value
+  length = 2
+}
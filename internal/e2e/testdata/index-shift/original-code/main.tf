@@ -0,0 +1,10 @@
+locals {
+  prefixes = ["alpha", "bravo", "charlie"]
+}
+
+resource "random_pet" "this" {
+  count = length(local.prefixes)
+
+  prefix = local.prefixes[count.index]
+  length = 2
+}
@@ -0,0 +1,19 @@
+resource "random_integer" "alpha" {
+  min = 1
+  max = 5
+}
+
+resource "random_integer" "beta" {
+  min = 6
+  max = 10
+}
+
+resource "random_pet" "alpha" {
+  length    = random_integer.alpha.result
+  separator = "-"
+}
+
+resource "random_pet" "beta" {
+  length    = random_integer.beta.result
+  separator = "-"
+}
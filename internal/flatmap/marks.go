@@ -0,0 +1,52 @@
+package flatmap
+
+// Marks flattens a tree of boolean markers, like the after_unknown or
+// after_sensitive fields of a Terraform plan, and returns the attributes
+// marked true. The tree mirrors the structure of the attributes it marks.
+//
+// Terraform uses a single boolean instead of a tree when no attribute is
+// marked, or when the whole object is. Both cases return no marks, so callers
+// must check AllMarked for the latter.
+func Marks(tree interface{}) (map[string]bool, error) {
+	if _, ok := tree.(map[string]interface{}); !ok {
+		return nil, nil
+	}
+
+	flat, err := Flatten(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	marks := make(map[string]bool)
+	for attr, v := range flat {
+		// Flattening lists adds a "#" attribute with the list's length, which
+		// is not a marker.
+		if v == true {
+			marks[attr] = true
+		}
+	}
+
+	return marks, nil
+}
+
+// AllMarked returns whether the tree marks the whole object it mirrors, rather
+// than some of its attributes.
+func AllMarked(tree interface{}) bool {
+	marked, ok := tree.(bool)
+	return ok && marked
+}
+
+// IsMarked returns whether attr is marked, either directly or because an
+// attribute containing it is. For example, if "tags" is marked, so is
+// "tags.name".
+func IsMarked(marks map[string]bool, attr string) bool {
+	if marks[attr] {
+		return true
+	}
+	for i := 0; i < len(attr); i++ {
+		if attr[i] == '.' && marks[attr[:i]] {
+			return true
+		}
+	}
+	return false
+}
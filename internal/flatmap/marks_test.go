@@ -0,0 +1,102 @@
+package flatmap_test
+
+import (
+	"testing"
+
+	"github.com/busser/tfautomv/internal/flatmap"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMarks(t *testing.T) {
+	tt := []struct {
+		name string
+		tree interface{}
+		want map[string]bool
+	}{
+		{
+			name: "no marks",
+			tree: false,
+			want: nil,
+		},
+		{
+			name: "nested",
+			tree: map[string]interface{}{
+				"password": true,
+				"name":     false,
+				"tags": map[string]interface{}{
+					"secret": true,
+				},
+				"keys": []interface{}{false, true},
+				"rules": []interface{}{
+					map[string]interface{}{"token": true},
+				},
+			},
+			want: map[string]bool{
+				"password":      true,
+				"tags.secret":   true,
+				"keys.1":        true,
+				"rules.0.token": true,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := flatmap.Marks(tc.tree)
+			if err != nil {
+				t.Fatalf("Marks() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Marks() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAllMarked(t *testing.T) {
+	tt := []struct {
+		name string
+		tree interface{}
+		want bool
+	}{
+		{"nil", nil, false},
+		{"no marks", false, false},
+		{"whole object", true, true},
+		{"some attributes", map[string]interface{}{"password": true}, false},
+	}
+
+	for _, tc := range tt {
+		if got := flatmap.AllMarked(tc.tree); got != tc.want {
+			t.Errorf("%s: AllMarked() = %t, want %t", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestIsMarked(t *testing.T) {
+	marks := map[string]bool{
+		"password":    true,
+		"tags":        true,
+		"rules.0.key": true,
+	}
+
+	tt := []struct {
+		attr string
+		want bool
+	}{
+		{"password", true},
+		{"password_length", false},
+		{"tags.name", true},
+		{"tags.#", true},
+		{"rules.0.key", true},
+		{"rules.0.name", false},
+		{"rules.#", false},
+		{"name", false},
+	}
+
+	for _, tc := range tt {
+		if got := flatmap.IsMarked(marks, tc.attr); got != tc.want {
+			t.Errorf("IsMarked(%q) = %t, want %t", tc.attr, got, tc.want)
+		}
+	}
+}
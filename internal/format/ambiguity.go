@@ -0,0 +1,62 @@
+package format
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/busser/tfautomv/internal/tfautomv"
+	"github.com/mitchellh/colorstring"
+)
+
+// Ambiguity describes a resource that matches several others, numbering each
+// match so that the user can choose one.
+func Ambiguity(amb tfautomv.Ambiguity) string {
+
+	c := colorstring.Colorize{
+		Colors:  colorstring.DefaultColors,
+		Reset:   true,
+		Disable: NoColor,
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString(c.Color("[bold][yellow]Ambiguous match"))
+	buf.WriteByte('\n')
+
+	operation := "destruction"
+	if !amb.IsCreated() {
+		operation = "creation"
+	}
+	buf.WriteString(c.Color(fmt.Sprintf("[bold]%s[reset] matches several resources planned for %s:", amb.Resource.Address, operation)))
+	buf.WriteByte('\n')
+
+	for i, comp := range amb.Matches {
+		other := comp.Destroyed
+		if !amb.IsCreated() {
+			other = comp.Created
+		}
+
+		var matchBuf bytes.Buffer
+		matchBuf.WriteString(c.Color(fmt.Sprintf("[bold]%d) [reset]%s", i+1, other.Address)))
+		matchBuf.WriteByte('\n')
+
+		var diffBuf bytes.Buffer
+		for _, attr := range comp.IgnoredAttributes {
+			createdValue, destroyedValue := comparedValues(comp, attr)
+			diffBuf.WriteString(c.Color(fmt.Sprintf("[yellow]~ [reset]%s (some differences are ignored)", attr)))
+			diffBuf.WriteByte('\n')
+			diffBuf.WriteString(c.Color(fmt.Sprintf("  [green]+ [reset]%s", createdValue)))
+			diffBuf.WriteByte('\n')
+			diffBuf.WriteString(c.Color(fmt.Sprintf("  [red]- [reset]%s", destroyedValue)))
+			diffBuf.WriteByte('\n')
+		}
+		writeUnknownAttributes(&diffBuf, c, comp)
+		if diffBuf.Len() > 0 {
+			matchBuf.WriteString(withLeftRule(&diffBuf, "yellow"))
+		}
+
+		buf.WriteString(withLeftRule(&matchBuf, "white"))
+	}
+
+	return withLeftRule(&buf, "yellow")
+}
@@ -0,0 +1,92 @@
+package format
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/busser/tfautomv/internal/tfautomv"
+)
+
+func TestAmbiguity(t *testing.T) {
+	created := &tfautomv.Resource{
+		Type:       "random_pet",
+		Address:    "random_pet.alpha",
+		Attributes: map[string]interface{}{"length": 2, "prefix": "Alpha"},
+	}
+	first := &tfautomv.Resource{
+		Type:       "random_pet",
+		Address:    "random_pet.first",
+		Attributes: map[string]interface{}{"length": 2, "prefix": "alpha"},
+	}
+	second := &tfautomv.Resource{
+		Type:       "random_pet",
+		Address:    "random_pet.second",
+		Attributes: map[string]interface{}{"length": 2, "prefix": "Alpha"},
+	}
+
+	amb := tfautomv.Ambiguity{
+		Resource: created,
+		Matches: []tfautomv.Comparison{
+			{
+				Created:            created,
+				Destroyed:          first,
+				MatchingAttributes: []string{"length"},
+				IgnoredAttributes:  []string{"prefix"},
+			},
+			{
+				Created:            created,
+				Destroyed:          second,
+				MatchingAttributes: []string{"length", "prefix"},
+			},
+		},
+	}
+
+	tt := []struct {
+		name string
+
+		noColor bool
+
+		want string
+	}{
+		{
+			name:    "ambiguity",
+			noColor: false,
+			want:    filepath.Join("testdata", "ambiguity", "ambiguity.txt"),
+		},
+		{
+			name:    "ambiguity no color",
+			noColor: true,
+			want:    filepath.Join("testdata", "ambiguity", "ambiguity-no-color.txt"),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			// Set NoColor for the duration of the test.
+			originalNoColor := NoColor
+			NoColor = tc.noColor
+			defer func() {
+				NoColor = originalNoColor
+			}()
+
+			actual := Ambiguity(amb)
+
+			if *update {
+				stringToFile(t, tc.want, actual)
+			}
+
+			want := stringFromFile(t, tc.want)
+
+			const escapeSequence = "\x1b"
+			if tc.noColor && strings.Contains(want, escapeSequence) {
+				t.Errorf("Ambiguity() output contains espace sequence %q even though color is disabled:\n%q", escapeSequence, want)
+			}
+
+			if want != actual {
+				t.Errorf("Ambiguity() mismatch\nWant:\n%s\nGot:\n%s", want, actual)
+			}
+		})
+	}
+}
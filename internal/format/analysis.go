@@ -3,11 +3,18 @@ package format
 import (
 	"bytes"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/busser/tfautomv/internal/tfautomv"
 	"github.com/mitchellh/colorstring"
 )
 
+// ConfiguredOnly determines whether the package's output lists the attributes
+// set in the Terraform configuration of each resource planned for creation.
+// It should be set when only those attributes are compared.
+var ConfiguredOnly bool
+
 func Analysis(analysis *tfautomv.Analysis) string {
 
 	c := colorstring.Colorize{
@@ -21,8 +28,14 @@ func Analysis(analysis *tfautomv.Analysis) string {
 	analysisBuf.WriteString(c.Color("[bold][cyan]Analysis"))
 	analysisBuf.WriteByte('\n')
 
-	for _, createdResources := range analysis.CreatedByType {
-		for _, created := range createdResources {
+	for _, group := range createdGroups(analysis) {
+		if GroupByModule {
+			analysisBuf.WriteByte('\n')
+			analysisBuf.WriteString(c.Color(fmt.Sprintf("[bold][underline]%s", moduleName(group.module))))
+			analysisBuf.WriteByte('\n')
+		}
+
+		for _, created := range group.resources {
 
 			// Display the resource planned for creation.
 
@@ -32,9 +45,22 @@ func Analysis(analysis *tfautomv.Analysis) string {
 
 			var resourceBuf bytes.Buffer
 
-			// List all resources planned for destruction that matched.
+			// List the attributes set in the Terraform configuration, if only
+			// those are compared and the plan includes it.
+
+			if ConfiguredOnly && created.Configured != nil {
+				configured := "(none)"
+				if len(created.Configured) > 0 {
+					configured = strings.Join(sortedKeys(created.Configured), ", ")
+				}
+				resourceBuf.WriteString(c.Color(fmt.Sprintf("[dim]Configured attributes: %s", configured)))
+				resourceBuf.WriteByte('\n')
+			}
 
-			for _, comp := range analysis.Comparisons[created] {
+			// List all resources planned for destruction, matches first, and
+			// include attributes that did not match.
+
+			for _, comp := range analysis.SortedComparisons(created) {
 				if comp.IsMatch() {
 					resourceBuf.WriteString(c.Color("[bold][green]Match: "))
 					resourceBuf.WriteString(comp.Destroyed.Address)
@@ -45,33 +71,38 @@ func Analysis(analysis *tfautomv.Analysis) string {
 						diffBuf.WriteString(c.Color(fmt.Sprintf("[yellow]~ [reset]%s (some differences are ignored)", attr)))
 						diffBuf.WriteByte('\n')
 					}
+					writeUnknownAttributes(&diffBuf, c, comp)
 					if diffBuf.Len() > 0 {
 						resourceBuf.WriteString(withLeftRule(&diffBuf, "green"))
 					}
+					continue
 				}
-			}
 
-			// List all resources planned for destruction that mismatched and
-			// include mismatching attributes.
-
-			for _, comp := range analysis.Comparisons[created] {
-				// Matching resources were already displayed above.
-				if comp.IsMatch() {
-					continue
+				// Comparisons without mismatching attributes can still be
+				// inconclusive.
+				label, color := "Mismatch", "red"
+				if len(comp.MismatchingAttributes) == 0 {
+					label, color = "Inconclusive", "yellow"
 				}
 
-				resourceBuf.WriteString(c.Color("[bold][red]Mismatch: "))
+				resourceBuf.WriteString(c.Color(fmt.Sprintf("[bold][%s]%s: ", color, label)))
 				resourceBuf.WriteString(comp.Destroyed.Address)
 				resourceBuf.WriteByte('\n')
 
 				var diffBuf bytes.Buffer
+				if comp.Inconclusive != "" {
+					diffBuf.WriteString(c.Color(fmt.Sprintf("[yellow]! [reset]%s", comp.Inconclusive)))
+					diffBuf.WriteByte('\n')
+				}
 				for _, attr := range comp.MismatchingAttributes {
-					diffBuf.WriteString(c.Color(fmt.Sprintf("[green]+ [reset]%s = %#v", attr, created.Attributes[attr])))
+					createdValue, destroyedValue := comparedValues(comp, attr)
+					diffBuf.WriteString(c.Color(fmt.Sprintf("[green]+ [reset]%s = %s", attr, createdValue)))
 					diffBuf.WriteByte('\n')
-					diffBuf.WriteString(c.Color(fmt.Sprintf("[red]- [reset]%s = %#v", attr, comp.Destroyed.Attributes[attr])))
+					diffBuf.WriteString(c.Color(fmt.Sprintf("[red]- [reset]%s = %s", attr, destroyedValue)))
 					diffBuf.WriteByte('\n')
 				}
-				resourceBuf.WriteString(withLeftRule(&diffBuf, "red"))
+				writeUnknownAttributes(&diffBuf, c, comp)
+				resourceBuf.WriteString(withLeftRule(&diffBuf, color))
 			}
 
 			analysisBuf.WriteString(withLeftRule(&resourceBuf, "white"))
@@ -84,3 +115,21 @@ func Analysis(analysis *tfautomv.Analysis) string {
 
 	return withLeftRule(&analysisBuf, "cyan")
 }
+
+// writeUnknownAttributes lists the attributes of the resource planned for
+// creation that are only known after apply.
+func writeUnknownAttributes(buf *bytes.Buffer, c colorstring.Colorize, comp tfautomv.Comparison) {
+	for _, attr := range comp.UnknownAttributes {
+		buf.WriteString(c.Color(fmt.Sprintf("[cyan]? [reset]%s (known after apply)", attr)))
+		buf.WriteByte('\n')
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
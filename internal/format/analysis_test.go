@@ -6,14 +6,283 @@ import (
 	"testing"
 
 	"github.com/busser/tfautomv/internal/tfautomv"
+	"github.com/busser/tfautomv/internal/tfautomv/ignore"
 )
 
 func TestAnalysis(t *testing.T) {
+	created := &tfautomv.Resource{
+		Type:       "random_pet",
+		Address:    "random_pet.refactored",
+		Attributes: map[string]interface{}{"length": 2},
+		Unknown:    map[string]bool{"id": true, "keepers.uuid": true},
+	}
+	first := &tfautomv.Resource{
+		Type:       "random_pet",
+		Address:    "random_pet.first",
+		Attributes: map[string]interface{}{"id": "brave-cat", "length": 2},
+	}
+	second := &tfautomv.Resource{
+		Type:       "random_pet",
+		Address:    "random_pet.second",
+		Attributes: map[string]interface{}{"id": "happy-dog", "length": 2},
+	}
+	sensitiveCreated := &tfautomv.Resource{
+		Type:       "random_password",
+		Address:    "random_password.refactored",
+		Attributes: map[string]interface{}{"length": 16, "result": "correct-horse"},
+		Sensitive:  map[string]bool{"result": true},
+	}
+	sensitiveDestroyed := &tfautomv.Resource{
+		Type:       "random_password",
+		Address:    "random_password.original",
+		Attributes: map[string]interface{}{"length": 16, "result": "hunter2"},
+		Sensitive:  map[string]bool{"result": true},
+	}
+	sensitiveAnalysis := &tfautomv.Analysis{
+		CreatedByType: map[string][]*tfautomv.Resource{
+			"random_password": {sensitiveCreated},
+		},
+		DestroyedByType: map[string][]*tfautomv.Resource{
+			"random_password": {sensitiveDestroyed},
+		},
+		Comparisons: map[*tfautomv.Resource][]tfautomv.Comparison{
+			sensitiveCreated: {
+				{
+					Created:               sensitiveCreated,
+					Destroyed:             sensitiveDestroyed,
+					MatchingAttributes:    []string{"length"},
+					MismatchingAttributes: []string{"result"},
+				},
+			},
+		},
+	}
+	configuredCreated := &tfautomv.Resource{
+		Type:       "aws_subnet",
+		Address:    "aws_subnet.refactored",
+		Attributes: map[string]interface{}{"cidr_block": "10.0.1.0/24", "map_public_ip_on_launch": false},
+		Configured: map[string]bool{"cidr_block": true, "tags.Name": true},
+	}
+	configuredDestroyed := &tfautomv.Resource{
+		Type:       "aws_subnet",
+		Address:    "aws_subnet.original",
+		Attributes: map[string]interface{}{"cidr_block": "10.0.1.0/24", "map_public_ip_on_launch": true},
+	}
+	configuredAnalysis := &tfautomv.Analysis{
+		CreatedByType: map[string][]*tfautomv.Resource{
+			"aws_subnet": {configuredCreated},
+		},
+		DestroyedByType: map[string][]*tfautomv.Resource{
+			"aws_subnet": {configuredDestroyed},
+		},
+		Comparisons: map[*tfautomv.Resource][]tfautomv.Comparison{
+			configuredCreated: {
+				{
+					Created:            configuredCreated,
+					Destroyed:          configuredDestroyed,
+					MatchingAttributes: []string{"cidr_block"},
+				},
+			},
+		},
+	}
+	// Comparisons made by tfautomv, rather than by hand, check that the output
+	// does not depend on the order attributes are found in.
+	comparedCreated := &tfautomv.Resource{
+		Type:    "random_pet",
+		Address: "random_pet.refactored",
+		Attributes: map[string]interface{}{
+			"keepers.alpha": "1",
+			"keepers.bravo": "2",
+			"length":        2,
+			"prefix":        "new",
+			"separator":     "-",
+			"tags.env":      "prod",
+			"tags.team":     "core",
+		},
+		Unknown: map[string]bool{"id": true, "keepers.charlie": true},
+	}
+	comparedDestroyed := &tfautomv.Resource{
+		Type:    "random_pet",
+		Address: "random_pet.original",
+		Attributes: map[string]interface{}{
+			"id":            "brave-cat",
+			"keepers.alpha": "3",
+			"keepers.bravo": "4",
+			"length":        3,
+			"prefix":        "old",
+			"separator":     "_",
+			"tags.env":      "PROD",
+			"tags.team":     "CORE",
+		},
+	}
+	comparedAnalysis := &tfautomv.Analysis{
+		CreatedByType: map[string][]*tfautomv.Resource{
+			"random_pet": {comparedCreated},
+		},
+		DestroyedByType: map[string][]*tfautomv.Resource{
+			"random_pet": {comparedDestroyed},
+		},
+		Comparisons: map[*tfautomv.Resource][]tfautomv.Comparison{
+			comparedCreated: {
+				tfautomv.Compare(comparedCreated, comparedDestroyed, []ignore.Rule{
+					ignore.MustParseRule("everything:random_pet:tags.env"),
+					ignore.MustParseRule("everything:random_pet:tags.team"),
+				}, tfautomv.AnalysisOptions{}),
+			},
+		},
+	}
+	rootPet := &tfautomv.Resource{
+		Type:       "random_pet",
+		Address:    "random_pet.this",
+		Attributes: map[string]interface{}{"length": 2},
+	}
+	modulePet := &tfautomv.Resource{
+		Type:          "random_pet",
+		Address:       "module.pets.random_pet.this",
+		ModuleAddress: "module.pets",
+		Attributes:    map[string]interface{}{"length": 3},
+	}
+	moduleID := &tfautomv.Resource{
+		Type:          "random_id",
+		Address:       "module.pets.random_id.this",
+		ModuleAddress: "module.pets",
+		Attributes:    map[string]interface{}{"byte_length": 8},
+	}
+	modulesAnalysis := &tfautomv.Analysis{
+		CreatedByType: map[string][]*tfautomv.Resource{
+			"random_pet": {rootPet, modulePet},
+			"random_id":  {moduleID},
+		},
+		DestroyedByType: map[string][]*tfautomv.Resource{},
+		Comparisons:     map[*tfautomv.Resource][]tfautomv.Comparison{},
+	}
+	petAlpha := &tfautomv.Resource{
+		Type:       "random_pet",
+		Address:    "random_pet.alpha",
+		Attributes: map[string]interface{}{"length": 2, "prefix": "alpha"},
+	}
+	petBravo := &tfautomv.Resource{
+		Type:       "random_pet",
+		Address:    "random_pet.bravo",
+		Attributes: map[string]interface{}{"length": 2, "prefix": "bravo"},
+	}
+	petOne := &tfautomv.Resource{
+		Type:       "random_pet",
+		Address:    "random_pet.this[1]",
+		Attributes: map[string]interface{}{"length": 2, "prefix": "bravo"},
+	}
+	petZero := &tfautomv.Resource{
+		Type:       "random_pet",
+		Address:    "random_pet.this[0]",
+		Attributes: map[string]interface{}{"length": 2, "prefix": "alpha"},
+	}
+	petTwo := &tfautomv.Resource{
+		Type:       "random_pet",
+		Address:    "random_pet.this[2]",
+		Attributes: map[string]interface{}{"length": 3, "prefix": "alpha"},
+	}
+	idCreated := &tfautomv.Resource{
+		Type:       "random_id",
+		Address:    "random_id.refactored",
+		Attributes: map[string]interface{}{"byte_length": 8},
+	}
+	idDestroyed := &tfautomv.Resource{
+		Type:       "random_id",
+		Address:    "random_id.original",
+		Attributes: map[string]interface{}{"byte_length": 4},
+	}
+	// Resources and comparisons are deliberately out of order, to check that
+	// the output is sorted.
+	completeAnalysis := &tfautomv.Analysis{
+		CreatedByType: map[string][]*tfautomv.Resource{
+			"random_pet": {petBravo, petAlpha},
+			"random_id":  {idCreated},
+		},
+		DestroyedByType: map[string][]*tfautomv.Resource{
+			"random_pet": {petTwo, petOne, petZero},
+			"random_id":  {idDestroyed},
+		},
+		Comparisons: map[*tfautomv.Resource][]tfautomv.Comparison{
+			petAlpha: {
+				{
+					Created:               petAlpha,
+					Destroyed:             petTwo,
+					MatchingAttributes:    []string{"prefix"},
+					MismatchingAttributes: []string{"length"},
+				},
+				{
+					Created:               petAlpha,
+					Destroyed:             petOne,
+					MatchingAttributes:    []string{"length"},
+					MismatchingAttributes: []string{"prefix"},
+				},
+				{
+					Created:            petAlpha,
+					Destroyed:          petZero,
+					MatchingAttributes: []string{"length", "prefix"},
+				},
+			},
+			petBravo: {
+				{
+					Created:               petBravo,
+					Destroyed:             petZero,
+					MatchingAttributes:    []string{"length"},
+					MismatchingAttributes: []string{"prefix"},
+				},
+				{
+					Created:            petBravo,
+					Destroyed:          petOne,
+					MatchingAttributes: []string{"length", "prefix"},
+					Inconclusive:       "only 2 attributes match, but at least 3 must match",
+				},
+				{
+					Created:               petBravo,
+					Destroyed:             petTwo,
+					MismatchingAttributes: []string{"length", "prefix"},
+				},
+			},
+			idCreated: {
+				{
+					Created:               idCreated,
+					Destroyed:             idDestroyed,
+					MismatchingAttributes: []string{"byte_length"},
+				},
+			},
+		},
+	}
+	unknownAnalysis := &tfautomv.Analysis{
+		CreatedByType: map[string][]*tfautomv.Resource{
+			"random_pet": {created},
+		},
+		DestroyedByType: map[string][]*tfautomv.Resource{
+			"random_pet": {first, second},
+		},
+		Comparisons: map[*tfautomv.Resource][]tfautomv.Comparison{
+			created: {
+				{
+					Created:            created,
+					Destroyed:          first,
+					MatchingAttributes: []string{"length"},
+					UnknownAttributes:  []string{"id", "keepers.uuid"},
+					Pinned:             true,
+				},
+				{
+					Created:            created,
+					Destroyed:          second,
+					MatchingAttributes: []string{"length"},
+					UnknownAttributes:  []string{"id", "keepers.uuid"},
+					Inconclusive:       "2 attributes are only known after apply, but only 1 attributes match",
+				},
+			},
+		},
+	}
+
 	tt := []struct {
 		name string
 
-		analysis *tfautomv.Analysis
-		noColor  bool
+		analysis       *tfautomv.Analysis
+		noColor        bool
+		groupByModule  bool
+		configuredOnly bool
 
 		want string
 	}{
@@ -29,19 +298,82 @@ func TestAnalysis(t *testing.T) {
 			noColor:  true,
 			want:     filepath.Join("testdata", "analysis", "empty-no-color.txt"),
 		},
-		// TODO(busser): add test cases for a complete analysis.
-		// {
-		// 	name:     "complete",
-		// 	analysis: &tfautomv.Analysis{},
-		// 	noColor:  false,
-		// 	want:     filepath.Join("testdata", "analysis", "complete.txt"),
-		// },
-		// {
-		// 	name:     "complete no color",
-		// 	analysis: &tfautomv.Analysis{},
-		// 	noColor:  true,
-		// 	want:     filepath.Join("testdata", "analysis", "complete-no-color.txt"),
-		// },
+		{
+			name:     "unknown attributes",
+			analysis: unknownAnalysis,
+			noColor:  false,
+			want:     filepath.Join("testdata", "analysis", "unknown.txt"),
+		},
+		{
+			name:     "unknown attributes no color",
+			analysis: unknownAnalysis,
+			noColor:  true,
+			want:     filepath.Join("testdata", "analysis", "unknown-no-color.txt"),
+		},
+		{
+			name:     "sensitive attributes",
+			analysis: sensitiveAnalysis,
+			noColor:  false,
+			want:     filepath.Join("testdata", "analysis", "sensitive.txt"),
+		},
+		{
+			name:     "sensitive attributes no color",
+			analysis: sensitiveAnalysis,
+			noColor:  true,
+			want:     filepath.Join("testdata", "analysis", "sensitive-no-color.txt"),
+		},
+		{
+			name:           "configured attributes",
+			analysis:       configuredAnalysis,
+			noColor:        false,
+			configuredOnly: true,
+			want:           filepath.Join("testdata", "analysis", "configured.txt"),
+		},
+		{
+			name:           "configured attributes no color",
+			analysis:       configuredAnalysis,
+			noColor:        true,
+			configuredOnly: true,
+			want:           filepath.Join("testdata", "analysis", "configured-no-color.txt"),
+		},
+		{
+			name:     "configured attributes not compared",
+			analysis: configuredAnalysis,
+			noColor:  true,
+			want:     filepath.Join("testdata", "analysis", "configured-not-compared.txt"),
+		},
+		{
+			name:     "compared attributes",
+			analysis: comparedAnalysis,
+			noColor:  true,
+			want:     filepath.Join("testdata", "analysis", "compared-no-color.txt"),
+		},
+		{
+			name:          "grouped by module",
+			analysis:      modulesAnalysis,
+			noColor:       false,
+			groupByModule: true,
+			want:          filepath.Join("testdata", "analysis", "modules.txt"),
+		},
+		{
+			name:          "grouped by module no color",
+			analysis:      modulesAnalysis,
+			noColor:       true,
+			groupByModule: true,
+			want:          filepath.Join("testdata", "analysis", "modules-no-color.txt"),
+		},
+		{
+			name:     "complete",
+			analysis: completeAnalysis,
+			noColor:  false,
+			want:     filepath.Join("testdata", "analysis", "complete.txt"),
+		},
+		{
+			name:     "complete no color",
+			analysis: completeAnalysis,
+			noColor:  true,
+			want:     filepath.Join("testdata", "analysis", "complete-no-color.txt"),
+		},
 	}
 
 	for _, tc := range tt {
@@ -54,6 +386,20 @@ func TestAnalysis(t *testing.T) {
 				NoColor = originalNoColor
 			}()
 
+			// Set ConfiguredOnly for the duration of the test.
+			originalConfiguredOnly := ConfiguredOnly
+			ConfiguredOnly = tc.configuredOnly
+			defer func() {
+				ConfiguredOnly = originalConfiguredOnly
+			}()
+
+			// Set GroupByModule for the duration of the test.
+			originalGroupByModule := GroupByModule
+			GroupByModule = tc.groupByModule
+			defer func() {
+				GroupByModule = originalGroupByModule
+			}()
+
 			actual := Analysis(tc.analysis)
 
 			if *update {
@@ -0,0 +1,126 @@
+package format
+
+import (
+	"encoding/json"
+
+	"github.com/busser/tfautomv/internal/terraform"
+	"github.com/busser/tfautomv/internal/tfautomv"
+)
+
+// JSONFormatVersion is the version of the document produced by JSON. The minor
+// version is incremented when backwards-compatible changes are made, like
+// adding a field. The major version is incremented for any other change.
+const JSONFormatVersion = "1.0"
+
+type jsonDocument struct {
+	FormatVersion string           `json:"format_version"`
+	Created       []jsonResource   `json:"created"`
+	Destroyed     []jsonResource   `json:"destroyed"`
+	Comparisons   []jsonComparison `json:"comparisons"`
+	Moves         []jsonMove       `json:"moves"`
+}
+
+type jsonResource struct {
+	Address       string `json:"address"`
+	Type          string `json:"type"`
+	ModuleAddress string `json:"module_address,omitempty"`
+	ProviderName  string `json:"provider_name,omitempty"`
+	Workdir       string `json:"workdir,omitempty"`
+}
+
+type jsonComparison struct {
+	Created               string   `json:"created"`
+	CreatedWorkdir        string   `json:"created_workdir,omitempty"`
+	Destroyed             string   `json:"destroyed"`
+	DestroyedWorkdir      string   `json:"destroyed_workdir,omitempty"`
+	Match                 bool     `json:"match"`
+	Score                 float64  `json:"score"`
+	MatchingAttributes    []string `json:"matching_attributes"`
+	IgnoredAttributes     []string `json:"ignored_attributes"`
+	MismatchingAttributes []string `json:"mismatching_attributes"`
+	UnknownAttributes     []string `json:"unknown_attributes"`
+	Inconclusive          string   `json:"inconclusive,omitempty"`
+}
+
+type jsonMove struct {
+	From          string `json:"from"`
+	FromWorkdir   string `json:"from_workdir,omitempty"`
+	To            string `json:"to"`
+	ToWorkdir     string `json:"to_workdir,omitempty"`
+	LowConfidence bool   `json:"low_confidence"`
+}
+
+// JSON returns a machine-readable document describing the analysis and the
+// moves tfautomv found. The document's structure is stable for a given
+// JSONFormatVersion, and its contents are sorted so that the same analysis
+// always produces the same document.
+func JSON(analysis *tfautomv.Analysis, moves []terraform.Move) (string, error) {
+	doc := jsonDocument{
+		FormatVersion: JSONFormatVersion,
+		Created:       jsonResources(analysis.Created()),
+		Destroyed:     jsonResources(analysis.Destroyed()),
+		Comparisons:   []jsonComparison{},
+		Moves:         []jsonMove{},
+	}
+
+	// Each comparison is indexed twice: once for the resource planned for
+	// creation and once for the resource planned for destruction. We only
+	// need to list it once.
+	for _, created := range analysis.Created() {
+		for _, comp := range analysis.SortedComparisons(created) {
+			doc.Comparisons = append(doc.Comparisons, jsonComparison{
+				Created:               comp.Created.Address,
+				CreatedWorkdir:        comp.Created.Workdir,
+				Destroyed:             comp.Destroyed.Address,
+				DestroyedWorkdir:      comp.Destroyed.Workdir,
+				Match:                 comp.IsMatch(),
+				Score:                 comp.Score(),
+				MatchingAttributes:    nonNil(comp.MatchingAttributes),
+				IgnoredAttributes:     nonNil(comp.IgnoredAttributes),
+				MismatchingAttributes: nonNil(comp.MismatchingAttributes),
+				UnknownAttributes:     nonNil(comp.UnknownAttributes),
+				Inconclusive:          comp.Inconclusive,
+			})
+		}
+	}
+
+	for _, m := range moves {
+		doc.Moves = append(doc.Moves, jsonMove{
+			From:          m.From,
+			FromWorkdir:   m.FromWorkdir,
+			To:            m.To,
+			ToWorkdir:     m.ToWorkdir,
+			LowConfidence: m.LowConfidence,
+		})
+	}
+
+	raw, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(raw) + "\n", nil
+}
+
+func jsonResources(rr []*tfautomv.Resource) []jsonResource {
+	resources := []jsonResource{}
+	for _, r := range rr {
+		resources = append(resources, jsonResource{
+			Address:       r.Address,
+			Type:          r.Type,
+			ModuleAddress: r.ModuleAddress,
+			ProviderName:  r.ProviderName,
+			Workdir:       r.Workdir,
+		})
+	}
+	return resources
+}
+
+// nonNil returns s, or an empty slice if s is nil, so that empty lists are
+// encoded as [] rather than null.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,99 @@
+package format
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/busser/tfautomv/internal/terraform"
+	"github.com/busser/tfautomv/internal/tfautomv"
+)
+
+func TestJSON(t *testing.T) {
+	createdPet := &tfautomv.Resource{
+		Type:    "random_pet",
+		Address: "random_pet.refactored",
+	}
+	destroyedPet := &tfautomv.Resource{
+		Type:    "random_pet",
+		Address: "random_pet.original",
+	}
+	createdID := &tfautomv.Resource{
+		Type:    "random_id",
+		Address: "random_id.refactored",
+	}
+	destroyedID := &tfautomv.Resource{
+		Type:    "random_id",
+		Address: "random_id.original",
+	}
+
+	petComparison := tfautomv.Comparison{
+		Created:            createdPet,
+		Destroyed:          destroyedPet,
+		MatchingAttributes: []string{"length", "separator"},
+		IgnoredAttributes:  []string{"prefix"},
+		UnknownAttributes:  []string{"id"},
+	}
+	idComparison := tfautomv.Comparison{
+		Created:               createdID,
+		Destroyed:             destroyedID,
+		MatchingAttributes:    []string{"prefix"},
+		MismatchingAttributes: []string{"byte_length"},
+	}
+
+	tt := []struct {
+		name string
+
+		analysis *tfautomv.Analysis
+		moves    []terraform.Move
+
+		want string
+	}{
+		{
+			name:     "empty",
+			analysis: &tfautomv.Analysis{},
+			want:     filepath.Join("testdata", "json", "empty.json"),
+		},
+		{
+			name: "complete",
+			analysis: &tfautomv.Analysis{
+				CreatedByType: map[string][]*tfautomv.Resource{
+					"random_pet": {createdPet},
+					"random_id":  {createdID},
+				},
+				DestroyedByType: map[string][]*tfautomv.Resource{
+					"random_pet": {destroyedPet},
+					"random_id":  {destroyedID},
+				},
+				Comparisons: map[*tfautomv.Resource][]tfautomv.Comparison{
+					createdPet:   {petComparison},
+					destroyedPet: {petComparison},
+					createdID:    {idComparison},
+					destroyedID:  {idComparison},
+				},
+			},
+			moves: []terraform.Move{
+				{From: "random_pet.original", To: "random_pet.refactored", LowConfidence: true},
+			},
+			want: filepath.Join("testdata", "json", "complete.json"),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := JSON(tc.analysis, tc.moves)
+			if err != nil {
+				t.Fatalf("JSON(): unexpected error: %v", err)
+			}
+
+			if *update {
+				stringToFile(t, tc.want, actual)
+			}
+
+			want := stringFromFile(t, tc.want)
+
+			if want != actual {
+				t.Errorf("JSON() mismatch\nWant:\n%s\nGot:\n%s", want, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package format
+
+import (
+	"sort"
+
+	"github.com/busser/tfautomv/internal/tfautomv"
+)
+
+// GroupByModule determines whether the package's output groups resources by
+// the module containing them.
+var GroupByModule bool
+
+type resourceGroup struct {
+	// Address of the module containing the resources, if resources are
+	// grouped by module.
+	module string
+
+	resources []*tfautomv.Resource
+}
+
+// createdGroups splits the resources planned for creation into groups, by
+// module if GroupByModule is set. Modules are sorted by address, and
+// resources within each module by type and address.
+func createdGroups(analysis *tfautomv.Analysis) []resourceGroup {
+	if !GroupByModule {
+		return []resourceGroup{{resources: analysis.Created()}}
+	}
+
+	var groups []resourceGroup
+	for module, resources := range analysis.CreatedByModule() {
+		groups = append(groups, resourceGroup{module: module, resources: resources})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].module < groups[j].module
+	})
+
+	return groups
+}
+
+func moduleName(address string) string {
+	if address == "" {
+		return "Root module"
+	}
+	return address
+}
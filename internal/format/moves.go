@@ -2,6 +2,7 @@ package format
 
 import (
 	"bytes"
+	"fmt"
 
 	"github.com/busser/tfautomv/internal/terraform"
 	"github.com/mitchellh/colorstring"
@@ -25,12 +26,23 @@ func Moves(moves []terraform.Move) string {
 
 		moveBuf.WriteString(c.Color("[bold]From: "))
 		moveBuf.WriteString(move.From)
+		if move.FromWorkdir != "" {
+			moveBuf.WriteString(fmt.Sprintf(" (in %s)", move.FromWorkdir))
+		}
 		moveBuf.WriteByte('\n')
 
 		moveBuf.WriteString(c.Color("[bold]To:   "))
 		moveBuf.WriteString(move.To)
+		if move.ToWorkdir != "" {
+			moveBuf.WriteString(fmt.Sprintf(" (in %s)", move.ToWorkdir))
+		}
 		moveBuf.WriteByte('\n')
 
+		if move.LowConfidence {
+			moveBuf.WriteString(c.Color("[yellow]Low confidence: this move was chosen by similarity score and some attributes may differ, please review it."))
+			moveBuf.WriteByte('\n')
+		}
+
 		buf.WriteString(withLeftRule(&moveBuf, "white"))
 	}
 
@@ -51,6 +51,40 @@ func TestMoves(t *testing.T) {
 			noColor: true,
 			want:    filepath.Join("testdata", "moves", "multiple-no-color.txt"),
 		},
+		{
+			name: "low confidence",
+			moves: []terraform.Move{
+				{From: "random_id.original", To: "random_id.refactored"},
+				{From: "random_pet.original", To: "random_pet.refactored", LowConfidence: true},
+			},
+			noColor: false,
+			want:    filepath.Join("testdata", "moves", "low-confidence.txt"),
+		},
+		{
+			name: "low confidence no color",
+			moves: []terraform.Move{
+				{From: "random_id.original", To: "random_id.refactored"},
+				{From: "random_pet.original", To: "random_pet.refactored", LowConfidence: true},
+			},
+			noColor: true,
+			want:    filepath.Join("testdata", "moves", "low-confidence-no-color.txt"),
+		},
+		{
+			name: "cross state",
+			moves: []terraform.Move{
+				{From: "random_pet.original", To: "random_pet.refactored", FromWorkdir: "monolith", ToWorkdir: "pets"},
+			},
+			noColor: false,
+			want:    filepath.Join("testdata", "moves", "cross-state.txt"),
+		},
+		{
+			name: "cross state no color",
+			moves: []terraform.Move{
+				{From: "random_pet.original", To: "random_pet.refactored", FromWorkdir: "monolith", ToWorkdir: "pets"},
+			},
+			noColor: true,
+			want:    filepath.Join("testdata", "moves", "cross-state-no-color.txt"),
+		},
 	}
 
 	for _, tc := range tt {
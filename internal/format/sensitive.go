@@ -0,0 +1,28 @@
+package format
+
+import (
+	"fmt"
+
+	"github.com/busser/tfautomv/internal/tfautomv"
+)
+
+// ShowSensitive determines whether the package's output includes the values of
+// sensitive attributes. If set to false, those values are redacted.
+var ShowSensitive bool
+
+// value formats an attribute's value for display, unless it is sensitive.
+func value(v interface{}, sensitive bool) string {
+	if sensitive && !ShowSensitive {
+		return "(sensitive value)"
+	}
+	return fmt.Sprintf("%#v", v)
+}
+
+// comparedValues formats the values an attribute has in both compared
+// resources. If the attribute is sensitive in either resource, both values
+// are redacted: they are likely to be similar secrets.
+func comparedValues(comp tfautomv.Comparison, attr string) (created, destroyed string) {
+	destroyedAttr := comp.DestroyedAttribute(attr)
+	sensitive := comp.Created.IsSensitive(attr) || comp.Destroyed.IsSensitive(destroyedAttr)
+	return value(comp.Created.Attributes[attr], sensitive), value(comp.Destroyed.Attributes[destroyedAttr], sensitive)
+}
@@ -0,0 +1,62 @@
+package format
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/busser/tfautomv/internal/terraform"
+	"github.com/mitchellh/colorstring"
+)
+
+// Verification describes the changes Terraform still plans to make after
+// tfautomv wrote its moves.
+func Verification(changes []terraform.Change) string {
+
+	c := colorstring.Colorize{
+		Colors:  colorstring.DefaultColors,
+		Reset:   true,
+		Disable: NoColor,
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString(c.Color("[bold][red]Remaining changes"))
+	buf.WriteByte('\n')
+
+	for _, change := range changes {
+		var changeBuf bytes.Buffer
+
+		changeBuf.WriteString(c.Color(fmt.Sprintf("[bold]%s[reset] will be %s", change.Address, actionVerbs[change.Action])))
+		changeBuf.WriteByte('\n')
+
+		var diffBuf bytes.Buffer
+		for _, d := range change.Diffs {
+			if d.Before != nil {
+				diffBuf.WriteString(c.Color(fmt.Sprintf("[red]- [reset]%s = %s", d.Attribute, value(d.Before, d.Sensitive))))
+				diffBuf.WriteByte('\n')
+			}
+			switch {
+			case d.AfterUnknown:
+				diffBuf.WriteString(c.Color(fmt.Sprintf("[green]+ [reset]%s = (known after apply)", d.Attribute)))
+				diffBuf.WriteByte('\n')
+			case d.After != nil:
+				diffBuf.WriteString(c.Color(fmt.Sprintf("[green]+ [reset]%s = %s", d.Attribute, value(d.After, d.Sensitive))))
+				diffBuf.WriteByte('\n')
+			}
+		}
+		if diffBuf.Len() > 0 {
+			changeBuf.WriteString(withLeftRule(&diffBuf, "red"))
+		}
+
+		buf.WriteString(withLeftRule(&changeBuf, "white"))
+	}
+
+	return withLeftRule(&buf, "red")
+}
+
+var actionVerbs = map[string]string{
+	"create":  "created",
+	"destroy": "destroyed",
+	"update":  "updated in-place",
+	"replace": "replaced",
+}
@@ -0,0 +1,96 @@
+package format
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/busser/tfautomv/internal/terraform"
+)
+
+func TestVerification(t *testing.T) {
+	changes := []terraform.Change{
+		{
+			Address: "random_pet.created",
+			Action:  "create",
+			Diffs: []terraform.AttributeDiff{
+				{Attribute: "id", AfterUnknown: true},
+				{Attribute: "length", After: float64(2)},
+			},
+		},
+		{
+			Address: "random_pet.updated",
+			Action:  "update",
+			Diffs: []terraform.AttributeDiff{
+				{Attribute: "keepers.owner", Before: "alice", After: "bob"},
+				{Attribute: "keepers.token", Before: "hunter2", After: "correct-horse", Sensitive: true},
+			},
+		},
+	}
+
+	tt := []struct {
+		name string
+
+		changes       []terraform.Change
+		noColor       bool
+		showSensitive bool
+
+		want string
+	}{
+		{
+			name:    "changes",
+			changes: changes,
+			noColor: false,
+			want:    filepath.Join("testdata", "verification", "changes.txt"),
+		},
+		{
+			name:    "changes no color",
+			changes: changes,
+			noColor: true,
+			want:    filepath.Join("testdata", "verification", "changes-no-color.txt"),
+		},
+		{
+			name:          "changes with sensitive values",
+			changes:       changes,
+			noColor:       true,
+			showSensitive: true,
+			want:          filepath.Join("testdata", "verification", "sensitive-no-color.txt"),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			// Set NoColor for the duration of the test.
+			originalNoColor := NoColor
+			NoColor = tc.noColor
+			defer func() {
+				NoColor = originalNoColor
+			}()
+
+			// Set ShowSensitive for the duration of the test.
+			originalShowSensitive := ShowSensitive
+			ShowSensitive = tc.showSensitive
+			defer func() {
+				ShowSensitive = originalShowSensitive
+			}()
+
+			actual := Verification(tc.changes)
+
+			if *update {
+				stringToFile(t, tc.want, actual)
+			}
+
+			want := stringFromFile(t, tc.want)
+
+			const escapeSequence = "\x1b"
+			if tc.noColor && strings.Contains(want, escapeSequence) {
+				t.Errorf("Verification() output contains espace sequence %q even though color is disabled:\n%q", escapeSequence, want)
+			}
+
+			if want != actual {
+				t.Errorf("Verification() mismatch\nWant:\n%s\nGot:\n%s", want, actual)
+			}
+		})
+	}
+}
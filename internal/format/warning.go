@@ -0,0 +1,24 @@
+package format
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/mitchellh/colorstring"
+)
+
+func Warning(msg string) string {
+
+	c := colorstring.Colorize{
+		Colors:  colorstring.DefaultColors,
+		Reset:   true,
+		Disable: NoColor,
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString(c.Color("[bold][yellow]Warning: [reset]\n\n"))
+	fmt.Fprintf(&buf, "%s\n", msg)
+
+	return withLeftRule(&buf, "yellow")
+}
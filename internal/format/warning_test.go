@@ -0,0 +1,103 @@
+package format
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/busser/tfautomv/internal/terraform"
+)
+
+func TestWarning(t *testing.T) {
+	importIDWarnings := terraform.ImportIDWarnings([]terraform.Import{
+		{To: "aws_iam_role_policy_attachment.this", ID: "admin-20230101"},
+		{To: "random_pet.this", ID: "happy-cat"},
+	})
+
+	tt := []struct {
+		name string
+
+		msg     string
+		noColor bool
+
+		want string
+	}{
+		{
+			name:    "simple",
+			msg:     "simple warning",
+			noColor: false,
+			want:    filepath.Join("testdata", "warning", "simple.txt"),
+		},
+		{
+			name:    "simple no color",
+			msg:     "simple warning",
+			noColor: true,
+			want:    filepath.Join("testdata", "warning", "simple-no-color.txt"),
+		},
+		{
+			name:    "multiline",
+			msg:     "multiple warnings:\n  - first warning\n  - second warning",
+			noColor: false,
+			want:    filepath.Join("testdata", "warning", "multiline.txt"),
+		},
+		{
+			name:    "multiline no color",
+			msg:     "multiple warnings:\n  - first warning\n  - second warning",
+			noColor: true,
+			want:    filepath.Join("testdata", "warning", "multiline-no-color.txt"),
+		},
+		{
+			name:    "import ID format",
+			msg:     importIDWarnings[0],
+			noColor: false,
+			want:    filepath.Join("testdata", "warning", "import-id.txt"),
+		},
+		{
+			name:    "import ID format no color",
+			msg:     importIDWarnings[0],
+			noColor: true,
+			want:    filepath.Join("testdata", "warning", "import-id-no-color.txt"),
+		},
+		{
+			name:    "import not supported",
+			msg:     importIDWarnings[1],
+			noColor: false,
+			want:    filepath.Join("testdata", "warning", "import-unsupported.txt"),
+		},
+		{
+			name:    "import not supported no color",
+			msg:     importIDWarnings[1],
+			noColor: true,
+			want:    filepath.Join("testdata", "warning", "import-unsupported-no-color.txt"),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+
+			// Set NoColor for the duration of the test.
+			originalNoColor := NoColor
+			NoColor = tc.noColor
+			defer func() {
+				NoColor = originalNoColor
+			}()
+
+			actual := Warning(tc.msg)
+
+			if *update {
+				stringToFile(t, tc.want, actual)
+			}
+
+			want := stringFromFile(t, tc.want)
+
+			const escapeSequence = "\x1b"
+			if tc.noColor && strings.Contains(want, escapeSequence) {
+				t.Errorf("Warning() output contains espace sequence %q even though color is disabled:\n%q", escapeSequence, want)
+			}
+
+			if want != actual {
+				t.Errorf("Warning() mismatch\nWant:\n%s\nGot:\n%s", want, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package pipeline
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/busser/tfautomv/internal/format"
+)
+
+// OutputOptions configure how Output reports the moves FindMoves found.
+type OutputOptions struct {
+	// Format is the output format: "json", or any format in Writers.
+	Format string
+
+	// DryRun prints the moves instead of writing them.
+	DryRun bool
+
+	// Writers write moves in each output format other than "json".
+	Writers map[string]func(res *Result) error
+
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Output reports the moves FindMoves found, in the chosen format.
+func Output(res *Result, opts OutputOptions) error {
+	// The JSON document describes the analysis in addition to any moves, so
+	// it is useful even when there are no moves to make.
+	if opts.Format == "json" && !opts.DryRun {
+		doc, err := format.JSON(res.Analysis, res.Moves)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(opts.Stdout, doc)
+		fmt.Fprint(opts.Stderr, format.Done(fmt.Sprintf("Wrote analysis and %d moves to standard output.", len(res.Moves))))
+		return nil
+	}
+
+	if len(res.Moves) == 0 {
+		fmt.Fprint(opts.Stderr, format.Done("Found no moves to make"))
+		return nil
+	}
+
+	// At this point, we need to output the moves we found. The Terraform
+	// community originally used `tf state mv` commands. Terraform 1.1+
+	// supports moved blocks as a replacement, but those remain incomplete for
+	// now. Community tools like tfmigrate are also popular, and some teams
+	// apply all state changes through them.
+
+	if opts.DryRun {
+		fmt.Fprint(opts.Stderr, format.Moves(res.Moves))
+		return nil
+	}
+
+	write, ok := opts.Writers[opts.Format]
+	if !ok {
+		return fmt.Errorf("unknown output format %q", opts.Format)
+	}
+
+	return write(res)
+}
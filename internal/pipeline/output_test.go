@@ -0,0 +1,123 @@
+package pipeline
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/busser/tfautomv/internal/format"
+	"github.com/busser/tfautomv/internal/terraform"
+	"github.com/busser/tfautomv/internal/tfautomv"
+)
+
+func TestOutput(t *testing.T) {
+	defer func(noColor bool) { format.NoColor = noColor }(format.NoColor)
+	format.NoColor = true
+
+	moves := []terraform.Move{
+		{From: "random_pet.original", To: "random_pet.refactored"},
+	}
+
+	tt := []struct {
+		name   string
+		format string
+		dryRun bool
+		moves  []terraform.Move
+
+		wantWritten bool
+		wantStdout  string
+		wantStderr  string
+		wantErr     bool
+	}{
+		{
+			name:       "json",
+			format:     "json",
+			moves:      moves,
+			wantStdout: `"format_version"`,
+			wantStderr: "Wrote analysis and 1 moves to standard output.",
+		},
+		{
+			name:       "json without moves",
+			format:     "json",
+			wantStdout: `"format_version"`,
+			wantStderr: "Wrote analysis and 0 moves to standard output.",
+		},
+		{
+			name:       "no moves",
+			format:     "blocks",
+			wantStderr: "Found no moves to make",
+		},
+		{
+			name:       "dry run",
+			format:     "blocks",
+			dryRun:     true,
+			moves:      moves,
+			wantStderr: "random_pet.refactored",
+		},
+		{
+			name:       "dry run with json",
+			format:     "json",
+			dryRun:     true,
+			moves:      moves,
+			wantStderr: "random_pet.refactored",
+		},
+		{
+			name:        "writer",
+			format:      "blocks",
+			moves:       moves,
+			wantWritten: true,
+		},
+		{
+			name:    "unknown format",
+			format:  "yaml",
+			moves:   moves,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			res := &Result{
+				Analysis: &tfautomv.Analysis{},
+				Moves:    tc.moves,
+			}
+
+			written := false
+			var stdout, stderr bytes.Buffer
+			err := Output(res, OutputOptions{
+				Format: tc.format,
+				DryRun: tc.dryRun,
+				Writers: map[string]func(*Result) error{
+					"blocks": func(*Result) error {
+						written = true
+						return nil
+					},
+				},
+				Stdout: &stdout,
+				Stderr: &stderr,
+			})
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("Output() should have returned an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Output() returned error: %v", err)
+			}
+
+			if written != tc.wantWritten {
+				t.Errorf("writer called = %t, want %t", written, tc.wantWritten)
+			}
+			if !strings.Contains(stdout.String(), tc.wantStdout) {
+				t.Errorf("standard output %q does not contain %q", stdout.String(), tc.wantStdout)
+			}
+			if tc.wantStdout == "" && stdout.Len() > 0 {
+				t.Errorf("standard output is %q, want nothing", stdout.String())
+			}
+			if !strings.Contains(stderr.String(), tc.wantStderr) {
+				t.Errorf("standard error %q does not contain %q", stderr.String(), tc.wantStderr)
+			}
+		})
+	}
+}
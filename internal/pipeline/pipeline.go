@@ -0,0 +1,139 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+
+	tfjson "github.com/hashicorp/terraform-json"
+
+	"github.com/busser/tfautomv/internal/slices"
+	"github.com/busser/tfautomv/internal/terraform"
+	"github.com/busser/tfautomv/internal/tfautomv"
+	"github.com/busser/tfautomv/internal/tfautomv/ignore"
+)
+
+// Options configure how FindMoves finds moves.
+type Options struct {
+	// Plan returns Terraform's plan for each working directory, indexed by
+	// the working directory's path, or by an empty string when there is a
+	// single working directory. Passes are numbered from 1.
+	Plan func(pass int) (map[string]*tfjson.Plan, error)
+
+	// Write writes the moves found during a pass to disk, so that the next
+	// pass's plan includes them, and returns how many moves it wrote. When
+	// Write is nil, FindMoves runs a single pass.
+	Write func(moves []terraform.Move) (int, error)
+
+	// Inspect is called with the analysis of each pass, along with the
+	// pairings that matched no resource, before moves are chosen. It can
+	// report on the analysis, or change it. Inspect can be nil.
+	Inspect func(analysis *tfautomv.Analysis, pass int, ignoredPairings []tfautomv.Pairing) error
+
+	Rules    []ignore.Rule
+	Analysis tfautomv.AnalysisOptions
+	Moves    tfautomv.MovesOptions
+	Pairings []tfautomv.Pairing
+
+	// Collapse replaces the moves of every instance of a module or resource
+	// with a move of the whole module or resource, when possible. It is only
+	// supported with a single working directory.
+	Collapse bool
+}
+
+// A Result describes the moves FindMoves found.
+type Result struct {
+	// Moves found during all passes, in the order they were found.
+	Moves []terraform.Move
+
+	// Analysis and Plans of the last pass.
+	Analysis *tfautomv.Analysis
+	Plans    map[string]*tfjson.Plan
+
+	// Passes is the number of passes run, and Written the number of moves
+	// written to disk during those passes.
+	Passes  int
+	Written int
+}
+
+// FindMoves finds moves by analysing Terraform's plan.
+//
+// Some moves can only be found once other moves are known. For example, a
+// resource whose attributes depend on another resource that moved will have
+// unknown attributes until Terraform knows about that move. When opts.Write is
+// set, FindMoves runs multiple passes: each pass writes the moves found so far
+// and plans again, until no new moves are found.
+func FindMoves(opts Options) (*Result, error) {
+	var res Result
+
+	for {
+		res.Passes++
+
+		plans, err := opts.Plan(res.Passes)
+		if err != nil {
+			return nil, err
+		}
+		res.Plans = plans
+
+		analysis, err := tfautomv.AnalysisFromPlans(plans, opts.Rules, opts.Analysis)
+		if err != nil {
+			return nil, err
+		}
+		res.Analysis = analysis
+
+		ignored, err := analysis.ApplyPairings(opts.Pairings)
+		if err != nil {
+			return nil, err
+		}
+
+		if opts.Inspect != nil {
+			if err := opts.Inspect(analysis, res.Passes, ignored); err != nil {
+				return nil, err
+			}
+		}
+
+		moves := tfautomv.MovesFromAnalysis(analysis, opts.Moves)
+		if len(moves) == 0 {
+			break
+		}
+		if opts.Collapse {
+			moves, err = collapse(moves, plans)
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, m := range moves {
+			if slices.Contains(res.Moves, m) {
+				return nil, fmt.Errorf("move from %s to %s was found again after being written to disk in a previous pass", m.From, m.To)
+			}
+		}
+		res.Moves = append(res.Moves, moves...)
+
+		if opts.Write == nil {
+			break
+		}
+
+		n, err := opts.Write(moves)
+		if err != nil {
+			return nil, err
+		}
+		res.Written += n
+		if n == 0 {
+			// Every move was already declared in the code, so planning
+			// again would not find anything new.
+			break
+		}
+	}
+
+	return &res, nil
+}
+
+// collapse collapses moves based on the only plan in plans.
+func collapse(moves []terraform.Move, plans map[string]*tfjson.Plan) ([]terraform.Move, error) {
+	if len(plans) != 1 {
+		return nil, errors.New("moves can only be collapsed in a single working directory")
+	}
+	for _, plan := range plans {
+		moves = terraform.CollapseMoves(moves, terraform.StateAddresses(plan.PriorState), terraform.CreatedAddresses(plan))
+	}
+	return moves, nil
+}
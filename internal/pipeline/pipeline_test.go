@@ -0,0 +1,191 @@
+package pipeline
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+
+	"github.com/busser/tfautomv/internal/terraform"
+)
+
+type fakeResource struct {
+	address    string
+	attributes map[string]interface{}
+	unknown    map[string]interface{}
+}
+
+func fakePlan(created, destroyed []fakeResource) map[string]*tfjson.Plan {
+	var plan tfjson.Plan
+
+	for _, r := range created {
+		typ, _, _ := strings.Cut(r.address, ".")
+		plan.ResourceChanges = append(plan.ResourceChanges, &tfjson.ResourceChange{
+			Address: r.address,
+			Type:    typ,
+			Change: &tfjson.Change{
+				Actions:      []tfjson.Action{tfjson.ActionCreate},
+				After:        r.attributes,
+				AfterUnknown: r.unknown,
+			},
+		})
+	}
+	for _, r := range destroyed {
+		typ, _, _ := strings.Cut(r.address, ".")
+		plan.ResourceChanges = append(plan.ResourceChanges, &tfjson.ResourceChange{
+			Address: r.address,
+			Type:    typ,
+			Change: &tfjson.Change{
+				Actions: []tfjson.Action{tfjson.ActionDelete},
+				Before:  r.attributes,
+			},
+		})
+	}
+
+	return map[string]*tfjson.Plan{"": &plan}
+}
+
+// multiPassPlans returns the plans Terraform makes when two random_integer
+// resources are renamed, along with the random_pet resources whose length is
+// each integer's result. The random_pet resources match each other until the
+// integers' moves are known, and Terraform knows their length.
+func multiPassPlans() []map[string]*tfjson.Plan {
+	unknownLength := map[string]interface{}{"length": true}
+
+	return []map[string]*tfjson.Plan{
+		fakePlan(
+			[]fakeResource{
+				{"random_integer.alpha", map[string]interface{}{"min": 1}, nil},
+				{"random_integer.beta", map[string]interface{}{"min": 6}, nil},
+				{"random_pet.alpha", map[string]interface{}{"separator": "-"}, unknownLength},
+				{"random_pet.beta", map[string]interface{}{"separator": "-"}, unknownLength},
+			},
+			[]fakeResource{
+				{"random_integer.first", map[string]interface{}{"min": 1}, nil},
+				{"random_integer.second", map[string]interface{}{"min": 6}, nil},
+				{"random_pet.first", map[string]interface{}{"separator": "-", "length": 3}, nil},
+				{"random_pet.second", map[string]interface{}{"separator": "-", "length": 8}, nil},
+			},
+		),
+		fakePlan(
+			[]fakeResource{
+				{"random_pet.alpha", map[string]interface{}{"separator": "-", "length": 3}, nil},
+				{"random_pet.beta", map[string]interface{}{"separator": "-", "length": 8}, nil},
+			},
+			[]fakeResource{
+				{"random_pet.first", map[string]interface{}{"separator": "-", "length": 3}, nil},
+				{"random_pet.second", map[string]interface{}{"separator": "-", "length": 8}, nil},
+			},
+		),
+		fakePlan(nil, nil),
+	}
+}
+
+func TestFindMoves(t *testing.T) {
+	integerMoves := []terraform.Move{
+		{From: "random_integer.first", To: "random_integer.alpha"},
+		{From: "random_integer.second", To: "random_integer.beta"},
+	}
+	petMoves := []terraform.Move{
+		{From: "random_pet.first", To: "random_pet.alpha"},
+		{From: "random_pet.second", To: "random_pet.beta"},
+	}
+
+	tt := []struct {
+		name     string
+		multi    bool
+		declared int
+
+		wantMoves   []terraform.Move
+		wantWrites  [][]terraform.Move
+		wantPasses  int
+		wantWritten int
+	}{
+		{
+			name:       "single pass",
+			wantMoves:  integerMoves,
+			wantPasses: 1,
+		},
+		{
+			name:        "multiple passes",
+			multi:       true,
+			wantMoves:   append(append([]terraform.Move(nil), integerMoves...), petMoves...),
+			wantWrites:  [][]terraform.Move{integerMoves, petMoves},
+			wantPasses:  3,
+			wantWritten: 4,
+		},
+		{
+			name:       "moves already declared",
+			multi:      true,
+			declared:   2,
+			wantMoves:  integerMoves,
+			wantWrites: [][]terraform.Move{integerMoves},
+			wantPasses: 1,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			plans := multiPassPlans()
+
+			var writes [][]terraform.Move
+			opts := Options{
+				Plan: func(pass int) (map[string]*tfjson.Plan, error) {
+					if pass > len(plans) {
+						t.Fatalf("unexpected pass %d", pass)
+					}
+					return plans[pass-1], nil
+				},
+			}
+			if tc.multi {
+				opts.Write = func(moves []terraform.Move) (int, error) {
+					writes = append(writes, moves)
+					return len(moves) - tc.declared, nil
+				}
+			}
+
+			res, err := FindMoves(opts)
+			if err != nil {
+				t.Fatalf("FindMoves() returned error: %v", err)
+			}
+
+			if !reflect.DeepEqual(res.Moves, tc.wantMoves) {
+				t.Errorf("Moves = %v, want %v", res.Moves, tc.wantMoves)
+			}
+			if !reflect.DeepEqual(writes, tc.wantWrites) {
+				t.Errorf("wrote %v, want %v", writes, tc.wantWrites)
+			}
+			if res.Passes != tc.wantPasses {
+				t.Errorf("Passes = %d, want %d", res.Passes, tc.wantPasses)
+			}
+			if res.Written != tc.wantWritten {
+				t.Errorf("Written = %d, want %d", res.Written, tc.wantWritten)
+			}
+			if res.Plans == nil || res.Analysis == nil {
+				t.Errorf("Plans and Analysis of the last pass should be set")
+			}
+		})
+	}
+}
+
+func TestFindMovesFoundAgain(t *testing.T) {
+	plan := fakePlan(
+		[]fakeResource{{"random_pet.alpha", map[string]interface{}{"length": 3}, nil}},
+		[]fakeResource{{"random_pet.first", map[string]interface{}{"length": 3}, nil}},
+	)
+
+	// Writing the move to disk has no effect on the next plan, so the same
+	// move is found again.
+	_, err := FindMoves(Options{
+		Plan: func(int) (map[string]*tfjson.Plan, error) {
+			return plan, nil
+		},
+		Write: func(moves []terraform.Move) (int, error) {
+			return len(moves), nil
+		},
+	})
+	if err == nil {
+		t.Errorf("FindMoves() should have returned an error")
+	}
+}
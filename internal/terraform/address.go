@@ -0,0 +1,137 @@
+package terraform
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// An address identifies a resource instance, a resource, or a module instance
+// in Terraform's state. For example:
+//
+//	module.network.module.subnets["public"].aws_subnet.this[0]
+//
+// is made of the modules `module.network` and `module.subnets["public"]`, the
+// resource `aws_subnet.this`, and the key `[0]`.
+type address struct {
+	// Each module in the path from the root module, with its key if any.
+	modules []string
+
+	// The resource's type and name, prefixed with "data." for data sources.
+	// Empty if the address is a module's.
+	resource string
+
+	// The resource instance's key, including brackets. Empty if the resource
+	// does not use count or for_each.
+	key string
+}
+
+func parseAddress(s string) (address, error) {
+	parts, err := splitAddress(s)
+	if err != nil {
+		return address{}, fmt.Errorf("invalid address %q: %w", s, err)
+	}
+
+	var addr address
+
+	i := 0
+	for i < len(parts) && parts[i] == "module" {
+		if i+1 >= len(parts) {
+			return address{}, fmt.Errorf("invalid address %q: missing module name", s)
+		}
+		addr.modules = append(addr.modules, "module."+parts[i+1])
+		i += 2
+	}
+
+	rest := parts[i:]
+	if len(rest) > 0 && rest[0] == "data" {
+		rest = rest[1:]
+		addr.resource = "data."
+	}
+
+	switch len(rest) {
+	case 0:
+		if addr.resource != "" {
+			return address{}, fmt.Errorf("invalid address %q: missing data source type", s)
+		}
+	case 2:
+		name := rest[1]
+		if i := strings.IndexByte(name, '['); i >= 0 {
+			name, addr.key = name[:i], name[i:]
+		}
+		addr.resource += rest[0] + "." + name
+	default:
+		return address{}, fmt.Errorf("invalid address %q: expected a resource type and name", s)
+	}
+
+	return addr, nil
+}
+
+// splitAddress splits an address on each dot, except for dots inside of
+// an instance key.
+func splitAddress(s string) ([]string, error) {
+	var (
+		parts    []string
+		current  strings.Builder
+		inKey    bool
+		inString bool
+		escaped  bool
+	)
+
+	for _, ch := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case inString && ch == '\\':
+			escaped = true
+		case inString && ch == '"':
+			inString = false
+		case inString:
+		case inKey && ch == '"':
+			inString = true
+		case inKey && ch == ']':
+			inKey = false
+		case ch == '[':
+			inKey = true
+		case !inKey && ch == '.':
+			parts = append(parts, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteRune(ch)
+	}
+
+	if inKey || inString {
+		return nil, errors.New("unterminated instance key")
+	}
+
+	parts = append(parts, current.String())
+	for _, p := range parts {
+		if p == "" {
+			return nil, errors.New("empty address part")
+		}
+	}
+
+	return parts, nil
+}
+
+// String returns the address in the same format Terraform uses.
+func (a address) String() string {
+	parts := make([]string, 0, len(a.modules)+1)
+	parts = append(parts, a.modules...)
+	if a.resource != "" {
+		parts = append(parts, a.resource+a.key)
+	}
+	return strings.Join(parts, ".")
+}
+
+// module returns the address of the module containing the resource.
+func (a address) module() string {
+	return strings.Join(a.modules, ".")
+}
+
+// withoutKey returns the address of the resource the instance belongs to.
+func (a address) withoutKey() address {
+	a.key = ""
+	return a
+}
@@ -0,0 +1,87 @@
+package terraform
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAddress(t *testing.T) {
+	tt := []struct {
+		s       string
+		want    address
+		wantErr bool
+	}{
+		{
+			s:    "random_pet.this",
+			want: address{resource: "random_pet.this"},
+		},
+		{
+			s:    "random_pet.this[0]",
+			want: address{resource: "random_pet.this", key: "[0]"},
+		},
+		{
+			s:    `random_pet.this["with.dots and \"quotes\"]"]`,
+			want: address{resource: "random_pet.this", key: `["with.dots and \"quotes\"]"]`},
+		},
+		{
+			s:    "data.random_pet.this",
+			want: address{resource: "data.random_pet.this"},
+		},
+		{
+			s: `module.a.module.b["foo"].random_pet.this[1]`,
+			want: address{
+				modules:  []string{"module.a", `module.b["foo"]`},
+				resource: "random_pet.this",
+				key:      "[1]",
+			},
+		},
+		{
+			s:    "module.a[0]",
+			want: address{modules: []string{"module.a[0]"}},
+		},
+		{
+			s:       "module",
+			wantErr: true,
+		},
+		{
+			s:       "random_pet",
+			wantErr: true,
+		},
+		{
+			s:       "random_pet.this.extra",
+			wantErr: true,
+		},
+		{
+			s:       `random_pet.this["foo`,
+			wantErr: true,
+		},
+		{
+			s:       "module..random_pet.this",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.s, func(t *testing.T) {
+			actual, err := parseAddress(tc.s)
+
+			if err != nil && !tc.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err == nil && tc.wantErr {
+				t.Errorf("expected error, got none")
+			}
+			if tc.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(actual, tc.want) {
+				t.Errorf("parseAddress() mismatch:\ngot: %#v\nwant: %#v", actual, tc.want)
+			}
+
+			if s := actual.String(); s != tc.s {
+				t.Errorf("String() = %q, want %q", s, tc.s)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package terraform
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	tfjson "github.com/hashicorp/terraform-json"
+
+	"github.com/busser/tfautomv/internal/flatmap"
+)
+
+// A Change is a change Terraform plans to make to a resource.
+type Change struct {
+	Address string
+
+	// Action is "create", "destroy", "update", or "replace".
+	Action string
+
+	// Diffs lists the attributes whose value changes, sorted by attribute.
+	Diffs []AttributeDiff
+}
+
+// An AttributeDiff is a change to a single attribute of a resource.
+type AttributeDiff struct {
+	Attribute string
+
+	// Before is nil if the attribute did not exist before the change.
+	Before interface{}
+
+	// After is nil if the attribute will not exist after the change, or if
+	// its value is unknown until apply.
+	After interface{}
+
+	// AfterUnknown is true if the attribute's value is only known after
+	// apply.
+	AfterUnknown bool
+
+	// Sensitive is true if the attribute's value should be kept secret,
+	// either before or after the change.
+	Sensitive bool
+}
+
+// PlannedChanges lists the changes Terraform plans to make to resources,
+// sorted by address. Data sources being read are not considered changes.
+func PlannedChanges(plan *tfjson.Plan) ([]Change, error) {
+	var changes []Change
+
+	for _, rc := range plan.ResourceChanges {
+		if rc.Change == nil || rc.Mode == tfjson.DataResourceMode {
+			continue
+		}
+
+		var action string
+		switch actions := rc.Change.Actions; {
+		case actions.Replace():
+			action = "replace"
+		case actions.Create():
+			action = "create"
+		case actions.Delete():
+			action = "destroy"
+		case actions.Update():
+			action = "update"
+		default:
+			continue
+		}
+
+		diffs, err := attributeDiffs(rc.Change)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", rc.Address, err)
+		}
+
+		changes = append(changes, Change{
+			Address: rc.Address,
+			Action:  action,
+			Diffs:   diffs,
+		})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Address < changes[j].Address
+	})
+
+	return changes, nil
+}
+
+func attributeDiffs(change *tfjson.Change) ([]AttributeDiff, error) {
+	before, err := flatmap.Flatten(change.Before)
+	if err != nil {
+		return nil, err
+	}
+	after, err := flatmap.Flatten(change.After)
+	if err != nil {
+		return nil, err
+	}
+
+	// Terraform uses a single boolean when the entire value is unknown.
+	var unknown map[string]interface{}
+	if _, ok := change.AfterUnknown.(map[string]interface{}); ok {
+		unknown, err = flatmap.Flatten(change.AfterUnknown)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	beforeSensitive, err := flatmap.Marks(change.BeforeSensitive)
+	if err != nil {
+		return nil, err
+	}
+	afterSensitive, err := flatmap.Marks(change.AfterSensitive)
+	if err != nil {
+		return nil, err
+	}
+
+	allSensitive := flatmap.AllMarked(change.BeforeSensitive) || flatmap.AllMarked(change.AfterSensitive)
+
+	attributes := make(map[string]bool)
+	for attr := range before {
+		attributes[attr] = true
+	}
+	for attr := range after {
+		attributes[attr] = true
+	}
+	for attr, v := range unknown {
+		if v == true {
+			attributes[attr] = true
+		}
+	}
+
+	var diffs []AttributeDiff
+	for attr := range attributes {
+		d := AttributeDiff{
+			Attribute:    attr,
+			Before:       before[attr],
+			After:        after[attr],
+			AfterUnknown: unknown[attr] == true,
+			Sensitive:    allSensitive || flatmap.IsMarked(beforeSensitive, attr) || flatmap.IsMarked(afterSensitive, attr),
+		}
+		if !d.AfterUnknown && reflect.DeepEqual(d.Before, d.After) {
+			continue
+		}
+		diffs = append(diffs, d)
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Attribute < diffs[j].Attribute
+	})
+
+	return diffs, nil
+}
@@ -0,0 +1,130 @@
+package terraform
+
+import (
+	"reflect"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestPlannedChanges(t *testing.T) {
+	plan := &tfjson.Plan{
+		ResourceChanges: []*tfjson.ResourceChange{
+			{
+				Address: "random_pet.unchanged",
+				Mode:    tfjson.ManagedResourceMode,
+				Change: &tfjson.Change{
+					Actions: tfjson.Actions{tfjson.ActionNoop},
+					Before:  map[string]interface{}{"length": float64(2)},
+					After:   map[string]interface{}{"length": float64(2)},
+				},
+			},
+			{
+				Address: "random_pet.updated",
+				Mode:    tfjson.ManagedResourceMode,
+				Change: &tfjson.Change{
+					Actions:         tfjson.Actions{tfjson.ActionUpdate},
+					Before:          map[string]interface{}{"length": float64(2), "prefix": "foo", "keepers": map[string]interface{}{"token": "a"}},
+					After:           map[string]interface{}{"length": float64(2), "prefix": "bar", "keepers": map[string]interface{}{"token": "b"}},
+					BeforeSensitive: map[string]interface{}{"keepers": true},
+					AfterSensitive:  map[string]interface{}{"keepers": true},
+				},
+			},
+			{
+				Address: "random_pet.created",
+				Mode:    tfjson.ManagedResourceMode,
+				Change: &tfjson.Change{
+					Actions:      tfjson.Actions{tfjson.ActionCreate},
+					After:        map[string]interface{}{"length": float64(2)},
+					AfterUnknown: map[string]interface{}{"id": true},
+				},
+			},
+			{
+				Address: "random_pet.destroyed",
+				Mode:    tfjson.ManagedResourceMode,
+				Change: &tfjson.Change{
+					Actions: tfjson.Actions{tfjson.ActionDelete},
+					Before:  map[string]interface{}{"id": "foo"},
+				},
+			},
+			{
+				Address: "random_pet.replaced",
+				Mode:    tfjson.ManagedResourceMode,
+				Change: &tfjson.Change{
+					Actions:      tfjson.Actions{tfjson.ActionDelete, tfjson.ActionCreate},
+					Before:       map[string]interface{}{"id": "foo", "length": float64(2)},
+					After:        map[string]interface{}{"length": float64(3)},
+					AfterUnknown: map[string]interface{}{"id": true},
+				},
+			},
+			{
+				Address: "random_password.secret",
+				Mode:    tfjson.ManagedResourceMode,
+				Change: &tfjson.Change{
+					Actions:         tfjson.Actions{tfjson.ActionUpdate},
+					Before:          map[string]interface{}{"length": float64(16)},
+					After:           map[string]interface{}{"length": float64(32)},
+					BeforeSensitive: true,
+					AfterSensitive:  true,
+				},
+			},
+			{
+				Address: "data.random_pet.read",
+				Mode:    tfjson.DataResourceMode,
+				Change: &tfjson.Change{
+					Actions: tfjson.Actions{tfjson.ActionRead},
+				},
+			},
+		},
+	}
+
+	want := []Change{
+		{
+			Address: "random_password.secret",
+			Action:  "update",
+			Diffs: []AttributeDiff{
+				{Attribute: "length", Before: float64(16), After: float64(32), Sensitive: true},
+			},
+		},
+		{
+			Address: "random_pet.created",
+			Action:  "create",
+			Diffs: []AttributeDiff{
+				{Attribute: "id", AfterUnknown: true},
+				{Attribute: "length", After: float64(2)},
+			},
+		},
+		{
+			Address: "random_pet.destroyed",
+			Action:  "destroy",
+			Diffs: []AttributeDiff{
+				{Attribute: "id", Before: "foo"},
+			},
+		},
+		{
+			Address: "random_pet.replaced",
+			Action:  "replace",
+			Diffs: []AttributeDiff{
+				{Attribute: "id", Before: "foo", AfterUnknown: true},
+				{Attribute: "length", Before: float64(2), After: float64(3)},
+			},
+		},
+		{
+			Address: "random_pet.updated",
+			Action:  "update",
+			Diffs: []AttributeDiff{
+				{Attribute: "keepers.token", Before: "a", After: "b", Sensitive: true},
+				{Attribute: "prefix", Before: "foo", After: "bar"},
+			},
+		},
+	}
+
+	actual, err := PlannedChanges(plan)
+	if err != nil {
+		t.Fatalf("PlannedChanges(): %v", err)
+	}
+
+	if !reflect.DeepEqual(actual, want) {
+		t.Errorf("PlannedChanges() mismatch:\ngot:  %#v\nwant: %#v", actual, want)
+	}
+}
@@ -0,0 +1,251 @@
+package terraform
+
+import (
+	"sort"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// CollapseMoves replaces the moves of every resource instance in a module, or
+// of every instance of a resource, with a single move of the whole module or
+// resource. This is only done when all instances move to the same new prefix.
+//
+// stateAddresses lists the addresses of all resource instances in Terraform's
+// state before any moves. A module or resource is only moved as a whole if
+// all of its instances in the state are moved, and if nothing in the state
+// already exists at its new address. Otherwise, CollapseMoves keeps the moves
+// of individual instances.
+//
+// createdAddresses lists the addresses of resource instances planned for
+// creation. If any of them is in a module or resource, that module or
+// resource is still declared in the configuration, so Terraform would reject
+// a move of it as a whole.
+func CollapseMoves(moves []Move, stateAddresses, createdAddresses []string) []Move {
+	inState := make(map[string]bool, len(stateAddresses))
+	var state []address
+	for _, s := range stateAddresses {
+		inState[s] = true
+		a, err := parseAddress(s)
+		if err != nil || strings.HasPrefix(a.resource, "data.") {
+			// Data sources are never moved. They are read again wherever
+			// they are in the code, so we can disregard them.
+			continue
+		}
+		state = append(state, a)
+	}
+
+	var created []address
+	for _, c := range createdAddresses {
+		a, err := parseAddress(c)
+		if err != nil {
+			continue
+		}
+		created = append(created, a)
+	}
+
+	// Prefer moving entire modules over moving entire resources, since this
+	// yields fewer moves.
+	collapsed, remaining := collapseBy(moves, inState, state, created, modulePrefixes)
+	more, remaining := collapseBy(remaining, inState, state, created, resourcePrefixes)
+
+	result := append(collapsed, more...)
+	result = append(result, remaining...)
+	sort.Sort(InOrder(result))
+
+	return result
+}
+
+// A prefixFunc returns the prefixes a move can be collapsed into, along with
+// a function that reports whether an address is under a prefix. If the move
+// cannot be collapsed, ok is false.
+type prefixFunc func(from, to address) (fromPrefix, toPrefix string, under func(a address, prefix string) bool, ok bool)
+
+// modulePrefixes collapses moves between modules: all of a module's contents
+// move to another module with the same structure.
+//
+//	module.a.module.c.aws_instance.this -> module.b.module.c.aws_instance.this
+//
+// collapses into:
+//
+//	module.a -> module.b
+func modulePrefixes(from, to address) (string, string, func(address, string) bool, bool) {
+	if from.resource != to.resource || from.key != to.key {
+		return "", "", nil, false
+	}
+
+	// Modules at the end of both paths that are identical are not part of the
+	// prefix.
+	i, j := len(from.modules), len(to.modules)
+	for i > 0 && j > 0 && from.modules[i-1] == to.modules[j-1] {
+		i--
+		j--
+	}
+
+	// Moving a resource into or out of the root module cannot be collapsed
+	// into a module move.
+	if i == 0 || j == 0 {
+		return "", "", nil, false
+	}
+
+	fromPrefix := strings.Join(from.modules[:i], ".")
+	toPrefix := strings.Join(to.modules[:j], ".")
+
+	return fromPrefix, toPrefix, isInModule, true
+}
+
+func isInModule(a address, module string) bool {
+	m := a.module()
+	return m == module || strings.HasPrefix(m, module+".")
+}
+
+// resourcePrefixes collapses moves between resources: all instances of a
+// resource move to another resource with the same keys.
+//
+//	aws_instance.a["foo"] -> aws_instance.b["foo"]
+//
+// collapses into:
+//
+//	aws_instance.a -> aws_instance.b
+func resourcePrefixes(from, to address) (string, string, func(address, string) bool, bool) {
+	if from.key == "" || from.key != to.key {
+		return "", "", nil, false
+	}
+
+	return from.withoutKey().String(), to.withoutKey().String(), isInstanceOf, true
+}
+
+func isInstanceOf(a address, resource string) bool {
+	return a.withoutKey().String() == resource
+}
+
+func collapseBy(moves []Move, inState map[string]bool, state, created []address, prefixes prefixFunc) (collapsed, remaining []Move) {
+	type prefixPair struct {
+		from, to string
+	}
+	type group struct {
+		moves []Move
+		under func(address, string) bool
+	}
+
+	groups := make(map[prefixPair]*group)
+	var order []prefixPair
+
+	for _, m := range moves {
+		from, errFrom := parseAddress(m.From)
+		to, errTo := parseAddress(m.To)
+		if errFrom != nil || errTo != nil {
+			remaining = append(remaining, m)
+			continue
+		}
+
+		fromPrefix, toPrefix, under, ok := prefixes(from, to)
+		if !ok {
+			remaining = append(remaining, m)
+			continue
+		}
+
+		p := prefixPair{fromPrefix, toPrefix}
+		if groups[p] == nil {
+			groups[p] = &group{under: under}
+			order = append(order, p)
+		}
+		groups[p].moves = append(groups[p].moves, m)
+	}
+
+	for _, p := range order {
+		g := groups[p]
+
+		// If we do not know about the instances being moved, we cannot be
+		// sure that the module or resource is moved in its entirety.
+		complete := true
+		movedFrom := make(map[string]bool, len(g.moves))
+		for _, m := range g.moves {
+			movedFrom[m.From] = true
+			if !inState[m.From] {
+				complete = false
+			}
+		}
+
+		for _, a := range state {
+			if !complete {
+				break
+			}
+			if g.under(a, p.from) && !movedFrom[a.String()] {
+				complete = false
+			}
+			if g.under(a, p.to) {
+				complete = false
+			}
+		}
+
+		// Something new is planned in the original module or resource, so
+		// it is still declared in the configuration.
+		for _, a := range created {
+			if !complete {
+				break
+			}
+			if g.under(a, p.from) {
+				complete = false
+			}
+		}
+
+		if !complete {
+			remaining = append(remaining, g.moves...)
+			continue
+		}
+
+		m := Move{From: p.from, To: p.to}
+		for _, gm := range g.moves {
+			m.LowConfidence = m.LowConfidence || gm.LowConfidence
+		}
+		collapsed = append(collapsed, m)
+	}
+
+	return collapsed, remaining
+}
+
+// CreatedAddresses returns the addresses of all resource instances the given
+// plan creates, including instances planned for replacement.
+func CreatedAddresses(plan *tfjson.Plan) []string {
+	if plan == nil {
+		return nil
+	}
+
+	var addresses []string
+	for _, rc := range plan.ResourceChanges {
+		if rc.Change == nil || rc.Mode == tfjson.DataResourceMode {
+			continue
+		}
+		if rc.Change.Actions.Create() || rc.Change.Actions.Replace() {
+			addresses = append(addresses, rc.Address)
+		}
+	}
+
+	return addresses
+}
+
+// StateAddresses returns the addresses of all resource instances in the given
+// state.
+func StateAddresses(state *tfjson.State) []string {
+	if state == nil || state.Values == nil {
+		return nil
+	}
+
+	var addresses []string
+	var walk func(m *tfjson.StateModule)
+	walk = func(m *tfjson.StateModule) {
+		if m == nil {
+			return
+		}
+		for _, r := range m.Resources {
+			addresses = append(addresses, r.Address)
+		}
+		for _, child := range m.ChildModules {
+			walk(child)
+		}
+	}
+	walk(state.Values.RootModule)
+
+	return addresses
+}
@@ -0,0 +1,221 @@
+package terraform
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCollapseMoves(t *testing.T) {
+	tt := []struct {
+		name    string
+		moves   []Move
+		state   []string
+		created []string
+		want    []Move
+	}{
+		{
+			name: "entire module",
+			moves: []Move{
+				{From: "module.a.random_pet.first", To: "module.b.random_pet.first"},
+				{From: "module.a.module.c.random_pet.second", To: "module.b.module.c.random_pet.second"},
+			},
+			state: []string{
+				"module.a.random_pet.first",
+				"module.a.module.c.random_pet.second",
+				"module.a.data.random_pet.ignored",
+				"random_pet.unrelated",
+			},
+			want: []Move{
+				{From: "module.a", To: "module.b"},
+			},
+		},
+		{
+			name: "nested module",
+			moves: []Move{
+				{From: "module.a.module.c.random_pet.first", To: "module.a.module.d.random_pet.first"},
+				{From: "module.a.module.c.random_pet.second", To: "module.a.module.d.random_pet.second"},
+			},
+			state: []string{
+				"module.a.random_pet.stays",
+				"module.a.module.c.random_pet.first",
+				"module.a.module.c.random_pet.second",
+			},
+			want: []Move{
+				{From: "module.a.module.c", To: "module.a.module.d"},
+			},
+		},
+		{
+			name: "incomplete module",
+			moves: []Move{
+				{From: "module.a.random_pet.first", To: "module.b.random_pet.first"},
+			},
+			state: []string{
+				"module.a.random_pet.first",
+				"module.a.random_pet.stays",
+			},
+			want: []Move{
+				{From: "module.a.random_pet.first", To: "module.b.random_pet.first"},
+			},
+		},
+		{
+			name: "module to root",
+			moves: []Move{
+				{From: "module.a.random_pet.first", To: "random_pet.first"},
+			},
+			state: []string{
+				"module.a.random_pet.first",
+			},
+			want: []Move{
+				{From: "module.a.random_pet.first", To: "random_pet.first"},
+			},
+		},
+		{
+			name: "destination already exists",
+			moves: []Move{
+				{From: "module.a.random_pet.first", To: "module.b.random_pet.first"},
+			},
+			state: []string{
+				"module.a.random_pet.first",
+				"module.b.random_pet.other",
+			},
+			want: []Move{
+				{From: "module.a.random_pet.first", To: "module.b.random_pet.first"},
+			},
+		},
+		{
+			name: "module instance",
+			moves: []Move{
+				{From: `module.a[0].random_pet.first`, To: `module.a["foo"].random_pet.first`},
+			},
+			state: []string{
+				`module.a[0].random_pet.first`,
+				`module.a[1].random_pet.first`,
+			},
+			want: []Move{
+				{From: `module.a[0]`, To: `module.a["foo"]`},
+			},
+		},
+		{
+			name: "entire resource",
+			moves: []Move{
+				{From: `random_pet.old["a"]`, To: `random_pet.new["a"]`},
+				{From: `random_pet.old["b"]`, To: `random_pet.new["b"]`},
+			},
+			state: []string{
+				`random_pet.old["a"]`,
+				`random_pet.old["b"]`,
+				`random_pet.older["c"]`,
+			},
+			want: []Move{
+				{From: "random_pet.old", To: "random_pet.new"},
+			},
+		},
+		{
+			name: "incomplete resource",
+			moves: []Move{
+				{From: `random_pet.old["a"]`, To: `random_pet.new["a"]`},
+				{From: `random_pet.old["b"]`, To: `random_pet.other["b"]`},
+			},
+			state: []string{
+				`random_pet.old["a"]`,
+				`random_pet.old["b"]`,
+			},
+			want: []Move{
+				{From: `random_pet.old["a"]`, To: `random_pet.new["a"]`},
+				{From: `random_pet.old["b"]`, To: `random_pet.other["b"]`},
+			},
+		},
+		{
+			name: "resource in incomplete module",
+			moves: []Move{
+				{From: `module.a.random_pet.this["a"]`, To: `module.b.random_pet.this["a"]`},
+				{From: `module.a.random_pet.this["b"]`, To: `module.b.random_pet.this["b"]`},
+			},
+			state: []string{
+				`module.a.random_pet.this["a"]`,
+				`module.a.random_pet.this["b"]`,
+				`module.a.random_pet.stays`,
+			},
+			want: []Move{
+				{From: "module.a.random_pet.this", To: "module.b.random_pet.this"},
+			},
+		},
+		{
+			name: "changed keys",
+			moves: []Move{
+				{From: "random_pet.this[0]", To: `random_pet.this["a"]`},
+			},
+			state: []string{
+				"random_pet.this[0]",
+			},
+			want: []Move{
+				{From: "random_pet.this[0]", To: `random_pet.this["a"]`},
+			},
+		},
+		{
+			name: "unknown state",
+			moves: []Move{
+				{From: `random_pet.old["a"]`, To: `random_pet.new["a"]`},
+			},
+			state: nil,
+			want: []Move{
+				{From: `random_pet.old["a"]`, To: `random_pet.new["a"]`},
+			},
+		},
+		{
+			name: "resource still declared",
+			moves: []Move{
+				{From: `random_pet.old["a"]`, To: `random_pet.new["a"]`},
+			},
+			state: []string{
+				`random_pet.old["a"]`,
+			},
+			created: []string{
+				`random_pet.new["a"]`,
+				`random_pet.old["b"]`,
+			},
+			want: []Move{
+				{From: `random_pet.old["a"]`, To: `random_pet.new["a"]`},
+			},
+		},
+		{
+			name: "module still called",
+			moves: []Move{
+				{From: "module.a.random_pet.first", To: "module.b.random_pet.first"},
+			},
+			state: []string{
+				"module.a.random_pet.first",
+			},
+			created: []string{
+				"module.b.random_pet.first",
+				"module.a.random_pet.new",
+			},
+			want: []Move{
+				{From: "module.a.random_pet.first", To: "module.b.random_pet.first"},
+			},
+		},
+		{
+			name: "low confidence",
+			moves: []Move{
+				{From: `random_pet.old["a"]`, To: `random_pet.new["a"]`},
+				{From: `random_pet.old["b"]`, To: `random_pet.new["b"]`, LowConfidence: true},
+			},
+			state: []string{
+				`random_pet.old["a"]`,
+				`random_pet.old["b"]`,
+			},
+			want: []Move{
+				{From: "random_pet.old", To: "random_pet.new", LowConfidence: true},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := CollapseMoves(tc.moves, tc.state, tc.created)
+			if !reflect.DeepEqual(actual, tc.want) {
+				t.Errorf("CollapseMoves() mismatch:\ngot: %#v\nwant: %#v", actual, tc.want)
+			}
+		})
+	}
+}
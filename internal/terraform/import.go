@@ -0,0 +1,224 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// An Import brings an existing resource under Terraform's management.
+type Import struct {
+	// Address the resource is imported to.
+	To string
+
+	// ID identifying the resource to its provider.
+	ID string
+
+	// Workdir is the working directory whose configuration the import
+	// belongs to. It is empty unless tfautomv compared several working
+	// directories.
+	Workdir string
+}
+
+func (i Import) Block() string {
+	return fmt.Sprintf("import {\n  to = %s\n  id = %s\n}", i.To, quote(i.ID))
+}
+
+// importIDFormats lists resource types whose import ID is not their id
+// attribute, along with the format of their import ID. An empty format means
+// the resource type cannot be imported at all.
+var importIDFormats = map[string]string{
+	"aws_iam_group_policy_attachment":  "<group>/<policy_arn>",
+	"aws_iam_role_policy_attachment":   "<role>/<policy_arn>",
+	"aws_iam_user_policy_attachment":   "<user>/<policy_arn>",
+	"aws_lambda_permission":            "<function_name>/<statement_id>",
+	"aws_route_table_association":      "<subnet_id>/<route_table_id>",
+	"aws_security_group_rule":          "<security_group_id>_<type>_<protocol>_<from_port>_<to_port>_<source>",
+	"google_project_iam_binding":       "<project> <role>",
+	"google_project_iam_member":        "<project> <role> <member>",
+	"google_storage_bucket_iam_member": "<bucket> <role> <member>",
+	"random_integer":                   "<result>,<min>,<max>",
+	"random_password":                  "<result>",
+	"random_pet":                       "",
+	"random_shuffle":                   "",
+	"tls_private_key":                  "",
+}
+
+// ImportIDWarnings returns a warning for each import of a resource type known
+// to use an import ID other than its id attribute.
+func ImportIDWarnings(imports []Import) []string {
+	var warnings []string
+
+	for _, i := range imports {
+		addr, err := parseAddress(i.To)
+		if err != nil {
+			continue
+		}
+		typ, _, _ := strings.Cut(addr.resource, ".")
+
+		format, ok := importIDFormats[typ]
+		switch {
+		case !ok:
+			continue
+		case format == "":
+			warnings = append(warnings, fmt.Sprintf("%s cannot be imported: %s resources do not support import.", i.To, typ))
+		default:
+			warnings = append(warnings, fmt.Sprintf("%s may not be imported correctly: %s resources are imported with an ID of the form %q, not their id attribute.", i.To, typ, format))
+		}
+	}
+
+	return warnings
+}
+
+// A Removal makes Terraform forget about a resource without destroying it.
+type Removal struct {
+	// Address of the resource to forget. Removed blocks do not support
+	// instance keys, so this is always the address of a resource or module.
+	From string
+
+	// Workdir is the working directory whose configuration the removal
+	// belongs to. It is empty unless tfautomv compared several working
+	// directories.
+	Workdir string
+}
+
+func (r Removal) Block() string {
+	return fmt.Sprintf("removed {\n  from = %s\n\n  lifecycle {\n    destroy = false\n  }\n}", r.From)
+}
+
+// RemovalsFromMoves returns the removals needed to take the source of each
+// move out of its state, without destroying anything. Removed blocks do not
+// support instance keys, so a removal applies to every instance of a resource,
+// in every instance of the modules containing it. inState lists the address
+// of every resource instance in each working directory's state. If a removal
+// would apply to an instance that is not moved, RemovalsFromMoves returns an
+// error, since Terraform would silently forget about that instance.
+//
+// created lists the address of every resource instance planned for creation
+// in each working directory. Terraform refuses to remove a resource that is
+// still declared, so RemovalsFromMoves also returns an error if a removal
+// applies to the destination of a move or to an instance planned for
+// creation.
+func RemovalsFromMoves(moves []Move, inState, created map[string][]string) ([]Removal, error) {
+	moved := make(map[Removal]map[string]bool)
+	var removals []Removal
+
+	for _, m := range moves {
+		from, err := removedAddress(m.From)
+		if err != nil {
+			return nil, err
+		}
+
+		r := Removal{
+			From:    from,
+			Workdir: m.FromWorkdir,
+		}
+		if moved[r] == nil {
+			moved[r] = make(map[string]bool)
+			removals = append(removals, r)
+		}
+		moved[r][m.From] = true
+	}
+
+	for _, r := range removals {
+		for _, instance := range inState[r.Workdir] {
+			from, err := removedAddress(instance)
+			if err != nil {
+				return nil, err
+			}
+			if from == r.From && !moved[r][instance] {
+				return nil, fmt.Errorf("cannot remove %s from the state without also removing %s, which is not moved", r.From, instance)
+			}
+		}
+
+		for _, m := range moves {
+			if m.ToWorkdir != r.Workdir {
+				continue
+			}
+			to, err := removedAddress(m.To)
+			if err != nil {
+				return nil, err
+			}
+			if to == r.From {
+				return nil, fmt.Errorf("cannot remove %s from the state since it is still declared: %s is moved to %s", r.From, m.From, m.To)
+			}
+		}
+
+		for _, instance := range created[r.Workdir] {
+			addr, err := removedAddress(instance)
+			if err != nil {
+				return nil, err
+			}
+			if addr == r.From {
+				return nil, fmt.Errorf("cannot remove %s from the state since it is still declared: %s is planned for creation", r.From, instance)
+			}
+		}
+	}
+
+	sort.Slice(removals, func(i, j int) bool {
+		if removals[i].Workdir != removals[j].Workdir {
+			return removals[i].Workdir < removals[j].Workdir
+		}
+		return removals[i].From < removals[j].From
+	})
+
+	return removals, nil
+}
+
+// removedAddress returns the address a removed block uses for the resource
+// instance at addr: without the instance key, or any module instance keys.
+func removedAddress(addr string) (string, error) {
+	a, err := parseAddress(addr)
+	if err != nil {
+		return "", err
+	}
+
+	for i, mod := range a.modules {
+		if j := strings.IndexByte(mod, '['); j >= 0 {
+			a.modules[i] = mod[:j]
+		}
+	}
+
+	return a.withoutKey().String(), nil
+}
+
+func AppendImportsToFile(imports []Import, path string) error {
+	blocks := make([]string, len(imports))
+	for i, imp := range imports {
+		blocks[i] = imp.Block()
+	}
+	return appendBlocksToFile(blocks, path)
+}
+
+func AppendRemovalsToFile(removals []Removal, path string) error {
+	blocks := make([]string, len(removals))
+	for i, r := range removals {
+		blocks[i] = r.Block()
+	}
+	return appendBlocksToFile(blocks, path)
+}
+
+func appendBlocksToFile(blocks []string, path string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	for _, b := range blocks {
+		fmt.Fprintln(f, b)
+	}
+
+	return nil
+}
+
+// quote returns s as an HCL string literal. Sequences that HCL would interpret
+// as templates are escaped.
+func quote(s string) string {
+	q := strconv.Quote(s)
+	q = strings.ReplaceAll(q, "${", "$${")
+	q = strings.ReplaceAll(q, "%{", "%%{")
+	return q
+}
@@ -0,0 +1,167 @@
+package terraform
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestImportBlock(t *testing.T) {
+	tt := []struct {
+		imp  Import
+		want string
+	}{
+		{
+			imp:  Import{To: "random_pet.this", ID: "happy-cat"},
+			want: "import {\n  to = random_pet.this\n  id = \"happy-cat\"\n}",
+		},
+		{
+			imp:  Import{To: `aws_s3_object.this["a"]`, ID: "bucket/${key}/%{x}\"quoted\""},
+			want: "import {\n  to = aws_s3_object.this[\"a\"]\n  id = \"bucket/$${key}/%%{x}\\\"quoted\\\"\"\n}",
+		},
+	}
+
+	for _, tc := range tt {
+		if actual := tc.imp.Block(); actual != tc.want {
+			t.Errorf("Block() mismatch:\ngot:\n%s\nwant:\n%s", actual, tc.want)
+		}
+	}
+}
+
+func TestRemovalsFromMoves(t *testing.T) {
+	moves := []Move{
+		{From: "random_pet.single", To: "random_pet.single", FromWorkdir: "monolith", ToWorkdir: "pets"},
+		{From: "random_pet.counted[0]", To: "random_pet.counted[0]", FromWorkdir: "monolith", ToWorkdir: "pets"},
+		{From: "random_pet.counted[1]", To: "random_pet.counted[1]", FromWorkdir: "monolith", ToWorkdir: "pets"},
+		{From: `module.pets["a"].random_pet.this`, To: "random_pet.a", FromWorkdir: "monolith", ToWorkdir: "pets"},
+		{From: "random_pet.other", To: "random_pet.other", FromWorkdir: "legacy", ToWorkdir: "pets"},
+	}
+
+	want := []Removal{
+		{From: "random_pet.other", Workdir: "legacy"},
+		{From: "module.pets.random_pet.this", Workdir: "monolith"},
+		{From: "random_pet.counted", Workdir: "monolith"},
+		{From: "random_pet.single", Workdir: "monolith"},
+	}
+
+	inState := map[string][]string{
+		"monolith": {
+			"random_pet.single",
+			"random_pet.counted[0]",
+			"random_pet.counted[1]",
+			`module.pets["a"].random_pet.this`,
+		},
+		"legacy": {
+			"random_pet.other",
+		},
+	}
+
+	actual, err := RemovalsFromMoves(moves, inState, nil)
+	if err != nil {
+		t.Fatalf("RemovalsFromMoves(): %v", err)
+	}
+
+	if !reflect.DeepEqual(actual, want) {
+		t.Errorf("RemovalsFromMoves() = %v, want %v", actual, want)
+	}
+}
+
+func TestRemovalsFromMovesPartial(t *testing.T) {
+	tt := []struct {
+		name    string
+		moves   []Move
+		inState []string
+		created []string
+		want    []Removal
+		wantErr bool
+	}{
+		{
+			name: "all instances moved",
+			moves: []Move{
+				{From: "random_pet.this[0]", To: `random_pet.that["a"]`},
+				{From: "random_pet.this[1]", To: `random_pet.that["b"]`},
+			},
+			inState: []string{"random_pet.this[0]", "random_pet.this[1]"},
+			want:    []Removal{{From: "random_pet.this"}},
+		},
+		{
+			name: "some instances moved",
+			moves: []Move{
+				{From: "random_pet.this[0]", To: `random_pet.that["a"]`},
+			},
+			inState: []string{"random_pet.this[0]", "random_pet.this[1]"},
+			wantErr: true,
+		},
+		{
+			name: "some module instances moved",
+			moves: []Move{
+				{From: `module.pets["a"].random_pet.this`, To: "random_pet.a"},
+			},
+			inState: []string{`module.pets["a"].random_pet.this`, `module.pets["b"].random_pet.this`},
+			wantErr: true,
+		},
+		{
+			name: "other resources in state",
+			moves: []Move{
+				{From: "random_pet.this", To: "random_pet.that"},
+			},
+			inState: []string{"random_pet.this", "random_pet.these[0]", "data.random_pet.this"},
+			want:    []Removal{{From: "random_pet.this"}},
+		},
+		{
+			name: "moved within the same resource",
+			moves: []Move{
+				{From: "random_pet.this[0]", To: `random_pet.this["a"]`},
+			},
+			inState: []string{"random_pet.this[0]"},
+			created: []string{`random_pet.this["a"]`},
+			wantErr: true,
+		},
+		{
+			name: "resource still declared",
+			moves: []Move{
+				{From: "random_pet.this[0]", To: `random_pet.that["a"]`},
+			},
+			inState: []string{"random_pet.this[0]"},
+			created: []string{`random_pet.that["a"]`, "random_pet.this[1]"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := RemovalsFromMoves(tc.moves, map[string][]string{"": tc.inState}, map[string][]string{"": tc.created})
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("RemovalsFromMoves() = %v, want an error", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RemovalsFromMoves(): %v", err)
+			}
+
+			if !reflect.DeepEqual(actual, tc.want) {
+				t.Errorf("RemovalsFromMoves() = %v, want %v", actual, tc.want)
+			}
+		})
+	}
+}
+
+func TestImportIDWarnings(t *testing.T) {
+	imports := []Import{
+		{To: "aws_instance.this", ID: "i-0123456789abcdef0"},
+		{To: `module.iam.aws_iam_role_policy_attachment.this["admin"]`, ID: "admin-20230101"},
+		{To: "random_pet.this", ID: "happy-cat"},
+	}
+
+	want := []string{
+		`module.iam.aws_iam_role_policy_attachment.this["admin"] may not be imported correctly: aws_iam_role_policy_attachment resources are imported with an ID of the form "<role>/<policy_arn>", not their id attribute.`,
+		"random_pet.this cannot be imported: random_pet resources do not support import.",
+	}
+
+	actual := ImportIDWarnings(imports)
+
+	if !reflect.DeepEqual(actual, want) {
+		t.Errorf("ImportIDWarnings() = %q, want %q", actual, want)
+	}
+}
@@ -0,0 +1,277 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// A Module is a directory of Terraform configuration files. Only files in the
+// native syntax are read; JSON configuration files are ignored.
+type Module struct {
+	// Directory containing the module's files.
+	Dir string
+
+	// Resources maps the address of each resource declared in the module,
+	// relative to the module, to the path of the file declaring it.
+	Resources map[string]string
+
+	// ModuleCalls maps the address of each module called by the module, like
+	// "module.network", to the call's details.
+	ModuleCalls map[string]ModuleCall
+
+	// MovedBlocks lists the moved blocks declared in the module.
+	MovedBlocks []MovedBlock
+}
+
+// A ModuleCall is a module block.
+type ModuleCall struct {
+	// Path to the file declaring the module block.
+	File string
+
+	// Source of the module, if it is a string literal. Empty otherwise.
+	Source string
+
+	// Repeated is true if the call uses count or for_each.
+	Repeated bool
+}
+
+// IsLocal returns whether the called module's code is in a local directory,
+// which tfautomv can safely edit.
+func (c ModuleCall) IsLocal() bool {
+	return strings.HasPrefix(c.Source, "./") || strings.HasPrefix(c.Source, "../")
+}
+
+var moduleSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "resource", LabelNames: []string{"type", "name"}},
+		{Type: "data", LabelNames: []string{"type", "name"}},
+		{Type: "module", LabelNames: []string{"name"}},
+		{Type: "moved"},
+	},
+}
+
+// LoadModule reads the Terraform configuration files in dir.
+func LoadModule(dir string) (*Module, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+
+	mod := Module{
+		Dir:         dir,
+		Resources:   make(map[string]string),
+		ModuleCalls: make(map[string]ModuleCall),
+	}
+
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		file, diags := hclsyntax.ParseConfig(src, path, hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+
+		// Terraform files contain many blocks tfautomv does not care about,
+		// so we only look at the ones in our schema.
+		content, _, diags := file.Body.PartialContent(moduleSchema)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+
+		for _, block := range content.Blocks {
+			switch block.Type {
+			case "resource":
+				mod.Resources[block.Labels[0]+"."+block.Labels[1]] = path
+			case "data":
+				mod.Resources["data."+block.Labels[0]+"."+block.Labels[1]] = path
+			case "module":
+				mod.ModuleCalls["module."+block.Labels[0]] = ModuleCall{
+					File:     path,
+					Source:   moduleSource(block.Body),
+					Repeated: isRepeated(block.Body),
+				}
+			case "moved":
+				b, err := parseMovedBlock(block, src)
+				if err != nil {
+					return nil, err
+				}
+				mod.MovedBlocks = append(mod.MovedBlocks, b)
+			}
+		}
+	}
+
+	return &mod, nil
+}
+
+func moduleSource(body hcl.Body) string {
+	attrs, _ := body.JustAttributes()
+	attr, ok := attrs["source"]
+	if !ok {
+		return ""
+	}
+
+	v, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || v.IsNull() || !v.IsKnown() || v.Type() != cty.String {
+		return ""
+	}
+
+	return v.AsString()
+}
+
+// isRepeated returns whether the block uses count or for_each.
+func isRepeated(body hcl.Body) bool {
+	attrs, _ := body.JustAttributes()
+	_, count := attrs["count"]
+	_, forEach := attrs["for_each"]
+	return count || forEach
+}
+
+// ColocateMoves decides where to write each move's moved block so that it is
+// next to the resource or module call it moves to. The moves are returned
+// grouped by the path of the file their block should be written to.
+//
+// When both of a move's addresses are inside the same call to a local module,
+// and that call does not use count or for_each, the moved block is written
+// inside the called module instead, with addresses relative to that module.
+// This only happens if no other module call uses the same code, since a moved
+// block applies to every call of the module declaring it.
+func ColocateMoves(moves []Move, dir string) (map[string][]Move, error) {
+	modules := make(map[string]*Module)
+	load := func(dir string) (*Module, error) {
+		dir = filepath.Clean(dir)
+		if mod, ok := modules[dir]; ok {
+			return mod, nil
+		}
+		mod, err := LoadModule(dir)
+		if err != nil {
+			return nil, err
+		}
+		modules[dir] = mod
+		return mod, nil
+	}
+
+	callers := make(map[string]int)
+	if err := countCallers(dir, load, callers, nil); err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]Move)
+	for _, m := range moves {
+		path, relative, err := colocateMove(m, dir, load, callers)
+		if err != nil {
+			return nil, err
+		}
+		files[path] = append(files[path], relative)
+	}
+
+	return files, nil
+}
+
+// countCallers counts how many module calls use the code of each local module
+// called, directly or not, by the module in dir. A module called by a module
+// that is itself called twice counts as called twice.
+func countCallers(dir string, load func(string) (*Module, error), callers map[string]int, visiting []string) error {
+	dir = filepath.Clean(dir)
+	for _, d := range visiting {
+		if d == dir {
+			return fmt.Errorf("module in %q calls itself", dir)
+		}
+	}
+
+	mod, err := load(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, call := range mod.ModuleCalls {
+		if !call.IsLocal() {
+			continue
+		}
+		called := filepath.Clean(filepath.Join(mod.Dir, call.Source))
+		callers[called]++
+		if err := countCallers(called, load, callers, append(visiting, dir)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func colocateMove(m Move, dir string, load func(string) (*Module, error), callers map[string]int) (string, Move, error) {
+	from, err := parseAddress(m.From)
+	if err != nil {
+		return "", Move{}, err
+	}
+	to, err := parseAddress(m.To)
+	if err != nil {
+		return "", Move{}, err
+	}
+
+	mod, err := load(dir)
+	if err != nil {
+		return "", Move{}, err
+	}
+
+	// Go down the module calls both addresses have in common, as long as we
+	// can write to the called module's code.
+	for len(from.modules) > 0 && len(to.modules) > 0 && from.modules[0] == to.modules[0] {
+		if strings.Contains(from.modules[0], "[") {
+			// A moved block inside the module would apply to all of the
+			// module's instances, not just this one.
+			break
+		}
+		if (len(from.modules) == 1 && from.resource == "") || (len(to.modules) == 1 && to.resource == "") {
+			// One of the addresses is the module call itself.
+			break
+		}
+
+		call, ok := mod.ModuleCalls[from.modules[0]]
+		if !ok || !call.IsLocal() {
+			break
+		}
+		called := filepath.Clean(filepath.Join(mod.Dir, call.Source))
+		if callers[called] != 1 {
+			// The module's code is shared with other calls, which a moved
+			// block inside it would also apply to.
+			break
+		}
+
+		mod, err = load(called)
+		if err != nil {
+			return "", Move{}, err
+		}
+		from.modules = from.modules[1:]
+		to.modules = to.modules[1:]
+	}
+
+	relative := m
+	relative.From = from.String()
+	relative.To = to.String()
+
+	if len(to.modules) > 0 {
+		name := to.modules[0]
+		if i := strings.IndexByte(name, '['); i >= 0 {
+			name = name[:i]
+		}
+		call, ok := mod.ModuleCalls[name]
+		if !ok {
+			return "", Move{}, fmt.Errorf("could not find the module block for %s in %q", name, mod.Dir)
+		}
+		return call.File, relative, nil
+	}
+
+	path, ok := mod.Resources[to.resource]
+	if !ok {
+		return "", Move{}, fmt.Errorf("could not find the resource block for %s in %q", to.resource, mod.Dir)
+	}
+	return path, relative, nil
+}
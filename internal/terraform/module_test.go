@@ -0,0 +1,164 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadModule(t *testing.T) {
+	dir := filepath.Join("testdata", "colocate")
+
+	mod, err := LoadModule(dir)
+	if err != nil {
+		t.Fatalf("LoadModule(): %v", err)
+	}
+
+	wantResources := map[string]string{
+		"random_pet.first":        filepath.Join(dir, "pets.tf"),
+		"random_pet.second":       filepath.Join(dir, "pets.tf"),
+		"data.random_pet.ignored": filepath.Join(dir, "pets.tf"),
+	}
+	if !reflect.DeepEqual(mod.Resources, wantResources) {
+		t.Errorf("Resources = %v, want %v", mod.Resources, wantResources)
+	}
+
+	wantCalls := map[string]ModuleCall{
+		"module.network":  {File: filepath.Join(dir, "main.tf"), Source: "./modules/network"},
+		"module.replicas": {File: filepath.Join(dir, "main.tf"), Source: "./modules/replica", Repeated: true},
+		"module.vpc":      {File: filepath.Join(dir, "main.tf"), Source: "terraform-aws-modules/vpc/aws"},
+	}
+	if !reflect.DeepEqual(mod.ModuleCalls, wantCalls) {
+		t.Errorf("ModuleCalls = %v, want %v", mod.ModuleCalls, wantCalls)
+	}
+}
+
+func TestColocateMoves(t *testing.T) {
+	dir := filepath.Join("testdata", "colocate")
+	root := func(name string) string { return filepath.Join(dir, name) }
+	network := func(name string) string { return filepath.Join(dir, "modules", "network", name) }
+	dns := func(name string) string { return filepath.Join(dir, "modules", "dns", name) }
+
+	tt := []struct {
+		name     string
+		move     Move
+		wantFile string
+		wantMove Move
+	}{
+		{
+			name:     "root resource",
+			move:     Move{From: "random_pet.old", To: "random_pet.first"},
+			wantFile: root("pets.tf"),
+			wantMove: Move{From: "random_pet.old", To: "random_pet.first"},
+		},
+		{
+			name:     "root resource instance",
+			move:     Move{From: "random_pet.first", To: "random_pet.second[1]", LowConfidence: true},
+			wantFile: root("pets.tf"),
+			wantMove: Move{From: "random_pet.first", To: "random_pet.second[1]", LowConfidence: true},
+		},
+		{
+			name:     "into module",
+			move:     Move{From: "random_pet.first", To: "module.network.random_pet.vpc"},
+			wantFile: root("main.tf"),
+			wantMove: Move{From: "random_pet.first", To: "module.network.random_pet.vpc"},
+		},
+		{
+			name:     "within module",
+			move:     Move{From: "module.network.random_pet.old", To: "module.network.random_pet.vpc"},
+			wantFile: network("main.tf"),
+			wantMove: Move{From: "random_pet.old", To: "random_pet.vpc"},
+		},
+		{
+			name:     "within nested module",
+			move:     Move{From: "module.network.module.dns.random_pet.old", To: "module.network.module.dns.random_pet.record"},
+			wantFile: dns("main.tf"),
+			wantMove: Move{From: "random_pet.old", To: "random_pet.record"},
+		},
+		{
+			name:     "within module called twice",
+			move:     Move{From: "module.network.module.public.random_pet.old", To: "module.network.module.public.random_pet.subnet"},
+			wantFile: network("main.tf"),
+			wantMove: Move{From: "module.public.random_pet.old", To: "module.public.random_pet.subnet"},
+		},
+		{
+			name:     "between nested modules",
+			move:     Move{From: "module.network.module.public.random_pet.subnet", To: "module.network.module.private.random_pet.subnet"},
+			wantFile: network("main.tf"),
+			wantMove: Move{From: "module.public.random_pet.subnet", To: "module.private.random_pet.subnet"},
+		},
+		{
+			name:     "entire nested module",
+			move:     Move{From: "module.network.module.public", To: "module.network.module.private"},
+			wantFile: network("main.tf"),
+			wantMove: Move{From: "module.public", To: "module.private"},
+		},
+		{
+			name:     "module instance",
+			move:     Move{From: "module.replicas[0].random_pet.old", To: "module.replicas[0].random_pet.vpc"},
+			wantFile: root("main.tf"),
+			wantMove: Move{From: "module.replicas[0].random_pet.old", To: "module.replicas[0].random_pet.vpc"},
+		},
+		{
+			name:     "remote module",
+			move:     Move{From: "module.vpc.aws_vpc.old", To: "module.vpc.aws_vpc.this[0]"},
+			wantFile: root("main.tf"),
+			wantMove: Move{From: "module.vpc.aws_vpc.old", To: "module.vpc.aws_vpc.this[0]"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := ColocateMoves([]Move{tc.move}, dir)
+			if err != nil {
+				t.Fatalf("ColocateMoves(): %v", err)
+			}
+
+			want := map[string][]Move{tc.wantFile: {tc.wantMove}}
+			if !reflect.DeepEqual(actual, want) {
+				t.Errorf("ColocateMoves() = %v, want %v", actual, want)
+			}
+		})
+	}
+}
+
+func TestColocateMovesMissingResource(t *testing.T) {
+	dir := filepath.Join("testdata", "colocate")
+
+	_, err := ColocateMoves([]Move{{From: "random_pet.first", To: "random_pet.missing"}}, dir)
+	if err == nil {
+		t.Errorf("expected error, got none")
+	}
+}
+
+func TestAppendMovesToFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.tf")
+	if err := os.WriteFile(path, []byte("resource \"random_pet\" \"this\" {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := AppendMovesToFiles(map[string][]Move{
+		path: {{From: "random_pet.old", To: "random_pet.this"}},
+	})
+	if err != nil {
+		t.Fatalf("AppendMovesToFiles(): %v", err)
+	}
+
+	actual, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `resource "random_pet" "this" {}
+
+moved {
+  from = random_pet.old
+  to   = random_pet.this
+}
+`
+	if string(actual) != want {
+		t.Errorf("file contents mismatch:\ngot:\n%s\nwant:\n%s", actual, want)
+	}
+}
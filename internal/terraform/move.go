@@ -4,26 +4,70 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 )
 
 type Move struct {
 	From string
 	To   string
+
+	// FromWorkdir and ToWorkdir are the working directories whose states
+	// contain From and To. They are empty unless tfautomv compared several
+	// working directories.
+	FromWorkdir string
+	ToWorkdir   string
+
+	// LowConfidence is true when the move was chosen by similarity score
+	// rather than because the resources fully match. Some of their attributes
+	// may differ, so a human should review it.
+	LowConfidence bool
 }
 
 func (m Move) Block() string {
-	return fmt.Sprintf("moved {\n  from = %s\n  to   = %s\n}", m.From, m.To)
+	block := fmt.Sprintf("moved {\n  from = %s\n  to   = %s\n}", m.From, m.To)
+	if m.LowConfidence {
+		block = "# tfautomv: low confidence, please review this move.\n" + block
+	}
+	return block
+}
+
+// CrossState returns whether the move is from one working directory's state
+// to another's.
+func (m Move) CrossState() bool {
+	return m.FromWorkdir != m.ToWorkdir
 }
 
 func AppendMovesToFile(moves []Move, path string) error {
-	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return err
+	blocks := make([]string, len(moves))
+	for i, m := range moves {
+		blocks[i] = m.Block()
 	}
-	defer f.Close()
+	return appendBlocksToFile(blocks, path)
+}
 
-	for _, m := range moves {
-		fmt.Fprintln(f, m.Block())
+// AppendMovesToFiles appends moved blocks to the end of each file, separated
+// from the file's existing contents by a blank line. It is meant to be used
+// with the result of ColocateMoves.
+func AppendMovesToFiles(files map[string][]Move) error {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range files[path] {
+			fmt.Fprintf(f, "\n%s\n", m.Block())
+		}
+
+		if err := f.Close(); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -31,6 +75,9 @@ func AppendMovesToFile(moves []Move, path string) error {
 
 func WriteMovesShellCommands(moves []Move, w io.Writer) {
 	for _, m := range moves {
+		if m.LowConfidence {
+			fmt.Fprintln(w, "# tfautomv: low confidence, please review this move.")
+		}
 		fmt.Fprintf(w, "terraform state mv %q %q\n", m.From, m.To)
 	}
 }
@@ -42,6 +89,9 @@ func (mm InOrder) Len() int {
 }
 
 func (mm InOrder) Less(i, j int) bool {
+	if mm[i].FromWorkdir != mm[j].FromWorkdir {
+		return mm[i].FromWorkdir < mm[j].FromWorkdir
+	}
 	if mm[i].From != mm[j].From {
 		return mm[i].From < mm[j].From
 	}
@@ -51,3 +101,46 @@ func (mm InOrder) Less(i, j int) bool {
 func (mm InOrder) Swap(i, j int) {
 	mm[i], mm[j] = mm[j], mm[i]
 }
+
+// WriteCrossStateShellCommands writes a shell script that moves resources
+// between the states of several working directories. Each state is pulled to
+// a local file, modified with `terraform state mv`, and pushed back. The
+// commands run terraformBin, the name or path of Terraform's binary.
+func WriteCrossStateShellCommands(moves []Move, terraformBin string, w io.Writer) {
+	var workdirs []string
+	seen := make(map[string]bool)
+	for _, m := range moves {
+		for _, dir := range []string{m.FromWorkdir, m.ToWorkdir} {
+			if !seen[dir] {
+				seen[dir] = true
+				workdirs = append(workdirs, dir)
+			}
+		}
+	}
+	sort.Strings(workdirs)
+
+	stateFile := make(map[string]string, len(workdirs))
+	for i, dir := range workdirs {
+		stateFile[dir] = fmt.Sprintf("tfautomv-%d.tfstate", i+1)
+	}
+
+	fmt.Fprintln(w, "set -e")
+	for _, dir := range workdirs {
+		fmt.Fprintf(w, "(cd %q && %s state pull) > %q\n", dir, terraformBin, stateFile[dir])
+	}
+
+	for _, m := range moves {
+		if m.LowConfidence {
+			fmt.Fprintln(w, "# tfautomv: low confidence, please review this move.")
+		}
+		if m.CrossState() {
+			fmt.Fprintf(w, "%s state mv -state=%q -state-out=%q %q %q\n", terraformBin, stateFile[m.FromWorkdir], stateFile[m.ToWorkdir], m.From, m.To)
+		} else {
+			fmt.Fprintf(w, "%s state mv -state=%q %q %q\n", terraformBin, stateFile[m.FromWorkdir], m.From, m.To)
+		}
+	}
+
+	for _, dir := range workdirs {
+		fmt.Fprintf(w, "(cd %q && %s state push -) < %q\n", dir, terraformBin, stateFile[dir])
+	}
+}
@@ -0,0 +1,30 @@
+package terraform
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteCrossStateShellCommands(t *testing.T) {
+	moves := []Move{
+		{From: "random_pet.a", To: "random_pet.a", FromWorkdir: "monolith", ToWorkdir: "pets"},
+		{From: "random_pet.b", To: "random_pet.c", FromWorkdir: "monolith", ToWorkdir: "monolith", LowConfidence: true},
+	}
+
+	want := `set -e
+(cd "monolith" && tofu state pull) > "tfautomv-1.tfstate"
+(cd "pets" && tofu state pull) > "tfautomv-2.tfstate"
+tofu state mv -state="tfautomv-1.tfstate" -state-out="tfautomv-2.tfstate" "random_pet.a" "random_pet.a"
+# tfautomv: low confidence, please review this move.
+tofu state mv -state="tfautomv-1.tfstate" "random_pet.b" "random_pet.c"
+(cd "monolith" && tofu state push -) < "tfautomv-1.tfstate"
+(cd "pets" && tofu state push -) < "tfautomv-2.tfstate"
+`
+
+	var buf bytes.Buffer
+	WriteCrossStateShellCommands(moves, "tofu", &buf)
+
+	if actual := buf.String(); actual != want {
+		t.Errorf("WriteCrossStateShellCommands() mismatch:\ngot:\n%s\nwant:\n%s", actual, want)
+	}
+}
@@ -0,0 +1,151 @@
+package terraform
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// A MovedBlock is a moved block found in a module's code.
+type MovedBlock struct {
+	Move
+
+	// Location of the block in its file.
+	defRange hcl.Range
+}
+
+// File returns the path to the file declaring the block.
+func (b MovedBlock) File() string {
+	return b.defRange.Filename
+}
+
+func parseMovedBlock(block *hcl.Block, src []byte) (MovedBlock, error) {
+	attrs, diags := block.Body.JustAttributes()
+	if diags.HasErrors() {
+		return MovedBlock{}, diags
+	}
+
+	from, ok := attrs["from"]
+	if !ok {
+		return MovedBlock{}, fmt.Errorf("%s: moved block has no \"from\" argument", block.DefRange)
+	}
+	to, ok := attrs["to"]
+	if !ok {
+		return MovedBlock{}, fmt.Errorf("%s: moved block has no \"to\" argument", block.DefRange)
+	}
+
+	// Addresses are traversals, not values, so we read them as written.
+	b := MovedBlock{
+		Move: Move{
+			From: strings.TrimSpace(string(from.Expr.Range().SliceBytes(src))),
+			To:   strings.TrimSpace(string(to.Expr.Range().SliceBytes(src))),
+		},
+		defRange: block.DefRange,
+	}
+
+	return b, nil
+}
+
+// LoadMovedBlocks reads the moved blocks declared in the module in dir and in
+// the local modules it calls, recursively. The addresses of blocks declared in
+// a called module are made relative to dir, once for each call to that module.
+//
+// A moved block inside a module called with count or for_each applies to each
+// of the module's instances, which a single address cannot describe. Those
+// blocks are ignored.
+func LoadMovedBlocks(dir string) ([]MovedBlock, error) {
+	return loadMovedBlocks(dir, "", nil)
+}
+
+func loadMovedBlocks(dir, prefix string, visiting map[string]bool) ([]MovedBlock, error) {
+	dir = filepath.Clean(dir)
+	if visiting[dir] {
+		return nil, fmt.Errorf("module in %q calls itself", dir)
+	}
+
+	mod, err := LoadModule(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []MovedBlock
+	for _, b := range mod.MovedBlocks {
+		b.From = prefix + b.From
+		b.To = prefix + b.To
+		blocks = append(blocks, b)
+	}
+
+	names := make([]string, 0, len(mod.ModuleCalls))
+	for name := range mod.ModuleCalls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		call := mod.ModuleCalls[name]
+		if !call.IsLocal() || call.Repeated {
+			continue
+		}
+
+		nested := make(map[string]bool, len(visiting)+1)
+		for d := range visiting {
+			nested[d] = true
+		}
+		nested[dir] = true
+
+		called, err := loadMovedBlocks(filepath.Join(dir, call.Source), prefix+name+".", nested)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, called...)
+	}
+
+	return blocks, nil
+}
+
+// ReconcileMoves compares moves with the moved blocks already declared in the
+// code, so that running tfautomv again never declares the same move twice. It
+// returns the moves that remain to be written.
+//
+// A move that an existing block already declares is dropped. A move that
+// continues the chain started by an existing block, like B -> C after A -> B,
+// is kept as is: the existing block may already have been applied, and
+// Terraform follows chains of moved blocks on its own. Moves that contradict
+// an existing block or that would create a cycle are reported as errors.
+func ReconcileMoves(moves []Move, blocks []MovedBlock) ([]Move, error) {
+	byFrom := make(map[string]MovedBlock, len(blocks))
+	byTo := make(map[string]MovedBlock, len(blocks))
+	for _, b := range blocks {
+		byFrom[b.From] = b
+		byTo[b.To] = b
+	}
+
+	var remaining []Move
+
+	for _, m := range moves {
+		if b, ok := byFrom[m.From]; ok {
+			if b.To == m.To {
+				continue
+			}
+			return nil, fmt.Errorf("cannot move %s to %s: moved block in %s already moves it to %s", m.From, m.To, b.File(), b.To)
+		}
+
+		if b, ok := byTo[m.To]; ok {
+			return nil, fmt.Errorf("cannot move %s to %s: moved block in %s already moves %s there", m.From, m.To, b.File(), b.From)
+		}
+
+		if b, ok := byFrom[m.To]; ok {
+			if b.To == m.From {
+				return nil, fmt.Errorf("cannot move %s to %s: moved block in %s moves it back, creating a cycle", m.From, m.To, b.File())
+			}
+			return nil, fmt.Errorf("cannot move %s to %s: moved block in %s already moves %s to %s", m.From, m.To, b.File(), b.From, b.To)
+		}
+
+		remaining = append(remaining, m)
+	}
+
+	return remaining, nil
+}
@@ -0,0 +1,198 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadModuleMovedBlocks(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "moves.tf")
+	src := `moved {
+  from = random_pet.a
+  to   = random_pet.b
+}
+
+moved {
+  from = module.network.random_pet.this["first"]
+  to   = module.vpc.random_pet.this["first"]
+}
+`
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mod, err := LoadModule(dir)
+	if err != nil {
+		t.Fatalf("LoadModule(): %v", err)
+	}
+
+	var actual []Move
+	for _, b := range mod.MovedBlocks {
+		if b.File() != path {
+			t.Errorf("File() = %q, want %q", b.File(), path)
+		}
+		actual = append(actual, b.Move)
+	}
+
+	want := []Move{
+		{From: "random_pet.a", To: "random_pet.b"},
+		{From: `module.network.random_pet.this["first"]`, To: `module.vpc.random_pet.this["first"]`},
+	}
+	if !reflect.DeepEqual(actual, want) {
+		t.Errorf("MovedBlocks = %v, want %v", actual, want)
+	}
+}
+
+func TestReconcileMoves(t *testing.T) {
+	existing := []MovedBlock{
+		{Move: Move{From: "random_pet.a", To: "random_pet.b"}},
+		{Move: Move{From: "random_pet.c", To: "random_pet.d"}},
+	}
+
+	tt := []struct {
+		name          string
+		moves         []Move
+		wantRemaining []Move
+		wantErr       bool
+		errContains   string
+	}{
+		{
+			name:          "new move",
+			moves:         []Move{{From: "random_pet.x", To: "random_pet.y"}},
+			wantRemaining: []Move{{From: "random_pet.x", To: "random_pet.y"}},
+		},
+		{
+			name:  "duplicate",
+			moves: []Move{{From: "random_pet.a", To: "random_pet.b"}},
+		},
+		{
+			name: "duplicate and new move",
+			moves: []Move{
+				{From: "random_pet.c", To: "random_pet.d"},
+				{From: "random_pet.x", To: "random_pet.y"},
+			},
+			wantRemaining: []Move{{From: "random_pet.x", To: "random_pet.y"}},
+		},
+		{
+			name:          "chain",
+			moves:         []Move{{From: "random_pet.b", To: "random_pet.e"}},
+			wantRemaining: []Move{{From: "random_pet.b", To: "random_pet.e"}},
+		},
+		{
+			name:    "same source, different target",
+			moves:   []Move{{From: "random_pet.a", To: "random_pet.e"}},
+			wantErr: true,
+		},
+		{
+			name:    "different source, same target",
+			moves:   []Move{{From: "random_pet.e", To: "random_pet.b"}},
+			wantErr: true,
+		},
+		{
+			name:    "source of existing block",
+			moves:   []Move{{From: "random_pet.e", To: "random_pet.a"}},
+			wantErr: true,
+		},
+		{
+			name:        "cycle",
+			moves:       []Move{{From: "random_pet.b", To: "random_pet.a"}},
+			wantErr:     true,
+			errContains: "creating a cycle",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			remaining, err := ReconcileMoves(tc.moves, existing)
+
+			if err != nil && !tc.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err == nil && tc.wantErr {
+				t.Fatalf("expected error, got none")
+			}
+			if tc.wantErr {
+				if !strings.Contains(err.Error(), tc.errContains) {
+					t.Errorf("error = %q, want it to contain %q", err, tc.errContains)
+				}
+				return
+			}
+
+			if !reflect.DeepEqual(remaining, tc.wantRemaining) {
+				t.Errorf("remaining moves = %v, want %v", remaining, tc.wantRemaining)
+			}
+		})
+	}
+}
+
+func TestLoadMovedBlocks(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.tf": `module "network" {
+  source = "./modules/network"
+}
+
+module "backup" {
+  source = "./modules/network"
+}
+
+module "zones" {
+  source   = "./modules/network"
+  for_each = toset(["a", "b"])
+}
+
+moved {
+  from = random_pet.a
+  to   = random_pet.b
+}
+`,
+		"modules/network/main.tf": `module "subnet" {
+  source = "../subnet"
+}
+
+moved {
+  from = aws_vpc.old
+  to   = aws_vpc.new
+}
+`,
+		"modules/subnet/main.tf": `moved {
+  from = aws_subnet.old
+  to   = aws_subnet.new
+}
+`,
+	}
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	blocks, err := LoadMovedBlocks(dir)
+	if err != nil {
+		t.Fatalf("LoadMovedBlocks(): %v", err)
+	}
+
+	var actual []Move
+	for _, b := range blocks {
+		actual = append(actual, b.Move)
+	}
+
+	want := []Move{
+		{From: "random_pet.a", To: "random_pet.b"},
+		{From: "module.backup.aws_vpc.old", To: "module.backup.aws_vpc.new"},
+		{From: "module.backup.module.subnet.aws_subnet.old", To: "module.backup.module.subnet.aws_subnet.new"},
+		{From: "module.network.aws_vpc.old", To: "module.network.aws_vpc.new"},
+		{From: "module.network.module.subnet.aws_subnet.old", To: "module.network.module.subnet.aws_subnet.new"},
+	}
+	if !reflect.DeepEqual(actual, want) {
+		t.Errorf("LoadMovedBlocks() = %v, want %v", actual, want)
+	}
+}
@@ -0,0 +1,56 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// rawState is the subset of Terraform's state format, as printed by
+// `terraform state pull`, that tfautomv reads. Unlike the state included in a
+// plan, it records which provider configuration manages each resource.
+type rawState struct {
+	Resources []struct {
+		Module   string `json:"module"`
+		Mode     string `json:"mode"`
+		Type     string `json:"type"`
+		Name     string `json:"name"`
+		Provider string `json:"provider"`
+	} `json:"resources"`
+}
+
+// StateProviders reads a state printed by `terraform state pull` and returns
+// the provider configuration managing each resource, indexed by the
+// resource's address without its instance key. Provider configurations are
+// described like `provider["registry.terraform.io/hashicorp/aws"].west`,
+// without the module declaring them.
+func StateProviders(raw string) (map[string]string, error) {
+	// Terraform prints nothing when there is no state yet.
+	if strings.TrimSpace(raw) == "" {
+		return map[string]string{}, nil
+	}
+
+	var state rawState
+	if err := json.Unmarshal([]byte(raw), &state); err != nil {
+		return nil, fmt.Errorf("invalid state: %w", err)
+	}
+
+	providers := make(map[string]string, len(state.Resources))
+	for _, r := range state.Resources {
+		addr := r.Type + "." + r.Name
+		if r.Mode == "data" {
+			addr = "data." + addr
+		}
+		if r.Module != "" {
+			addr = r.Module + "." + addr
+		}
+
+		provider := r.Provider
+		if i := strings.Index(provider, "provider["); i >= 0 {
+			provider = provider[i:]
+		}
+		providers[addr] = provider
+	}
+
+	return providers, nil
+}
@@ -0,0 +1,63 @@
+package terraform
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStateProviders(t *testing.T) {
+	raw := `{
+  "version": 4,
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "aws_s3_bucket",
+      "name": "logs",
+      "provider": "provider[\"registry.terraform.io/hashicorp/aws\"].west",
+      "instances": [{"attributes": {"bucket": "logs"}}]
+    },
+    {
+      "module": "module.storage[\"a\"]",
+      "mode": "managed",
+      "type": "aws_s3_bucket",
+      "name": "this",
+      "provider": "module.storage.provider[\"registry.terraform.io/hashicorp/aws\"]",
+      "instances": [{"index_key": 0}, {"index_key": 1}]
+    },
+    {
+      "mode": "data",
+      "type": "aws_region",
+      "name": "current",
+      "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+      "instances": []
+    }
+  ]
+}`
+
+	want := map[string]string{
+		"aws_s3_bucket.logs":                     `provider["registry.terraform.io/hashicorp/aws"].west`,
+		`module.storage["a"].aws_s3_bucket.this`: `provider["registry.terraform.io/hashicorp/aws"]`,
+		"data.aws_region.current":                `provider["registry.terraform.io/hashicorp/aws"]`,
+	}
+
+	actual, err := StateProviders(raw)
+	if err != nil {
+		t.Fatalf("StateProviders(): %v", err)
+	}
+
+	if !reflect.DeepEqual(actual, want) {
+		t.Errorf("StateProviders() = %v, want %v", actual, want)
+	}
+
+	empty, err := StateProviders("")
+	if err != nil {
+		t.Fatalf("StateProviders() on empty state: %v", err)
+	}
+	if len(empty) != 0 {
+		t.Errorf("StateProviders() on empty state = %v, want none", empty)
+	}
+
+	if _, err := StateProviders("not json"); err == nil {
+		t.Errorf("StateProviders() on invalid state: expected error, got none")
+	}
+}
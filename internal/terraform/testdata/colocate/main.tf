@@ -0,0 +1,13 @@
+module "network" {
+  source = "./modules/network"
+}
+
+module "replicas" {
+  source = "./modules/replica"
+  count  = 2
+}
+
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.0.0"
+}
@@ -0,0 +1,13 @@
+resource "random_pet" "vpc" {}
+
+module "public" {
+  source = "../subnet"
+}
+
+module "private" {
+  source = "../subnet"
+}
+
+module "dns" {
+  source = "../dns"
+}
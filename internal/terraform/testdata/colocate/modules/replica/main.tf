@@ -0,0 +1 @@
+resource "random_pet" "vpc" {}
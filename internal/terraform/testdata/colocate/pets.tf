@@ -0,0 +1,7 @@
+resource "random_pet" "first" {}
+
+resource "random_pet" "second" {
+  count = 2
+}
+
+data "random_pet" "ignored" {}
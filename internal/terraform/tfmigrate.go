@@ -0,0 +1,37 @@
+package terraform
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// WriteTfmigrateMigration writes a tfmigrate migration file that moves
+// resources in Terraform's state. The migration is of the "state" type, and
+// its name is the given name. See https://github.com/minamijoyo/tfmigrate for
+// details.
+func WriteTfmigrateMigration(moves []Move, name string, w io.Writer) {
+	fmt.Fprintf(w, "migration \"state\" %s {\n", quote(name))
+	fmt.Fprintln(w, "  actions = [")
+	for _, m := range moves {
+		if m.LowConfidence {
+			fmt.Fprintln(w, "    # tfautomv: low confidence, please review this move.")
+		}
+		action := fmt.Sprintf("mv %s %s", tfmigrateArg(m.From), tfmigrateArg(m.To))
+		fmt.Fprintf(w, "    %s,\n", quote(action))
+	}
+	fmt.Fprintln(w, "  ]")
+	fmt.Fprintln(w, "}")
+}
+
+var safeTfmigrateArg = regexp.MustCompile(`^[A-Za-z0-9_.\-\[\]]+$`)
+
+// tfmigrateArg quotes an address so that tfmigrate parses it as a single
+// argument. Tfmigrate splits actions into arguments like a shell would.
+func tfmigrateArg(s string) string {
+	if safeTfmigrateArg.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
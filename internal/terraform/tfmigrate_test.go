@@ -0,0 +1,29 @@
+package terraform
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteTfmigrateMigration(t *testing.T) {
+	moves := []Move{
+		{From: "random_pet.original", To: "random_pet.refactored"},
+		{From: "random_pet.counted[0]", To: `random_pet.each["first"]`, LowConfidence: true},
+	}
+
+	want := `migration "state" "tfautomv" {
+  actions = [
+    "mv random_pet.original random_pet.refactored",
+    # tfautomv: low confidence, please review this move.
+    "mv random_pet.counted[0] 'random_pet.each[\"first\"]'",
+  ]
+}
+`
+
+	var buf bytes.Buffer
+	WriteTfmigrateMigration(moves, "tfautomv", &buf)
+
+	if actual := buf.String(); actual != want {
+		t.Errorf("WriteTfmigrateMigration() mismatch:\ngot:\n%s\nwant:\n%s", actual, want)
+	}
+}
@@ -0,0 +1,87 @@
+package tfautomv
+
+import "fmt"
+
+// An Ambiguity is a resource that matches several resources, so tfautomv cannot
+// tell which of them it should be paired with.
+type Ambiguity struct {
+	Resource *Resource
+
+	// Comparisons between Resource and each resource it matches.
+	Matches []Comparison
+}
+
+// IsCreated returns whether the ambiguous resource is planned for creation,
+// rather than destruction.
+func (a Ambiguity) IsCreated() bool {
+	return len(a.Matches) > 0 && a.Matches[0].Created == a.Resource
+}
+
+// Ambiguities lists resources that match more than one resource. Resources
+// planned for creation come first, and resources are sorted by type and
+// address within each group.
+func (a *Analysis) Ambiguities() []Ambiguity {
+	var ambiguities []Ambiguity
+
+	for _, resources := range [][]*Resource{a.Created(), a.Destroyed()} {
+		for _, r := range resources {
+			var matches []Comparison
+			for _, comp := range a.SortedComparisons(r) {
+				if comp.IsMatch() {
+					matches = append(matches, comp)
+				}
+			}
+			if len(matches) > 1 {
+				ambiguities = append(ambiguities, Ambiguity{Resource: r, Matches: matches})
+			}
+		}
+	}
+
+	return ambiguities
+}
+
+// Pin pairs two resources, as if they matched each other and only each other.
+// All other comparisons involving either resource are removed from the
+// analysis, so other resources can no longer match them. The analysis must
+// include a comparison between the two resources.
+func (a *Analysis) Pin(created, destroyed *Resource) error {
+	var pinned *Comparison
+	for _, comp := range a.Comparisons[created] {
+		if comp.Destroyed == destroyed {
+			c := comp
+			pinned = &c
+		}
+	}
+	if pinned == nil {
+		return fmt.Errorf("cannot pair %s with %s: they were not compared", destroyed.Address, created.Address)
+	}
+
+	a.forget(created)
+	a.forget(destroyed)
+
+	pinned.Pinned = true
+	a.Comparisons[created] = []Comparison{*pinned}
+	a.Comparisons[destroyed] = []Comparison{*pinned}
+
+	return nil
+}
+
+// forget removes all comparisons involving r from the analysis.
+func (a *Analysis) forget(r *Resource) {
+	for _, comp := range a.Comparisons[r] {
+		other := comp.Created
+		if other == r {
+			other = comp.Destroyed
+		}
+
+		var kept []Comparison
+		for _, c := range a.Comparisons[other] {
+			if c.Created != r && c.Destroyed != r {
+				kept = append(kept, c)
+			}
+		}
+		a.Comparisons[other] = kept
+	}
+
+	delete(a.Comparisons, r)
+}
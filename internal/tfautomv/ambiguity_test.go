@@ -0,0 +1,100 @@
+package tfautomv
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/busser/tfautomv/internal/terraform"
+)
+
+func TestAmbiguities(t *testing.T) {
+	plan := dummyPlanWithAttributes(t,
+		[]dummyResourceWithAttributes{
+			{"random_pet.alpha", "random_pet", map[string]interface{}{"length": 2}},
+			{"random_pet.beta", "random_pet", map[string]interface{}{"length": 2}},
+			{"random_pet.gamma", "random_pet", map[string]interface{}{"length": 3}},
+		},
+		[]dummyResourceWithAttributes{
+			{"random_pet.first", "random_pet", map[string]interface{}{"length": 2}},
+			{"random_pet.second", "random_pet", map[string]interface{}{"length": 2}},
+			{"random_pet.third", "random_pet", map[string]interface{}{"length": 3}},
+		},
+	)
+
+	analysis, err := AnalysisFromPlan(plan, nil, AnalysisOptions{})
+	if err != nil {
+		t.Fatalf("AnalysisFromPlan() returned error: %v", err)
+	}
+
+	var got []string
+	for _, amb := range analysis.Ambiguities() {
+		if len(amb.Matches) != 2 {
+			t.Errorf("%s has %d matches, want 2", amb.Resource.Address, len(amb.Matches))
+		}
+		got = append(got, amb.Resource.Address)
+	}
+
+	want := []string{
+		"random_pet.alpha",
+		"random_pet.beta",
+		"random_pet.first",
+		"random_pet.second",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Ambiguities() = %v, want %v", got, want)
+	}
+}
+
+func TestPin(t *testing.T) {
+	plan := dummyPlanWithAttributes(t,
+		[]dummyResourceWithAttributes{
+			{"random_pet.alpha", "random_pet", map[string]interface{}{"length": 2}},
+			{"random_pet.beta", "random_pet", map[string]interface{}{"length": 2}},
+		},
+		[]dummyResourceWithAttributes{
+			{"random_pet.first", "random_pet", map[string]interface{}{"length": 2}},
+			{"random_pet.second", "random_pet", map[string]interface{}{"length": 2}},
+		},
+	)
+
+	analysis, err := AnalysisFromPlan(plan, nil, AnalysisOptions{})
+	if err != nil {
+		t.Fatalf("AnalysisFromPlan() returned error: %v", err)
+	}
+
+	resources := make(map[string]*Resource)
+	for _, byType := range []map[string][]*Resource{analysis.CreatedByType, analysis.DestroyedByType} {
+		for _, rr := range byType {
+			for _, r := range rr {
+				resources[r.Address] = r
+			}
+		}
+	}
+
+	// Pinning one pair leaves a single possible pair for the other resources,
+	// so no ambiguity remains.
+	if err := analysis.Pin(resources["random_pet.alpha"], resources["random_pet.second"]); err != nil {
+		t.Fatalf("Pin() returned error: %v", err)
+	}
+
+	if amb := analysis.Ambiguities(); len(amb) != 0 {
+		t.Errorf("Ambiguities() returned %d ambiguities after Pin(), want 0", len(amb))
+	}
+
+	got := MovesFromAnalysis(analysis, MovesOptions{})
+	want := []terraform.Move{
+		{From: "random_pet.first", To: "random_pet.beta"},
+		{From: "random_pet.second", To: "random_pet.alpha"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MovesFromAnalysis() = %v, want %v", got, want)
+	}
+
+	// The first pin removed the comparison between these resources.
+	if err := analysis.Pin(resources["random_pet.alpha"], resources["random_pet.first"]); err == nil {
+		t.Errorf("Pin() of resources that were not compared should have returned an error")
+	}
+	if got := MovesFromAnalysis(analysis, MovesOptions{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("MovesFromAnalysis() after failed Pin() = %v, want %v", got, want)
+	}
+}
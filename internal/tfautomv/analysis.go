@@ -1,6 +1,9 @@
 package tfautomv
 
 import (
+	"fmt"
+	"sort"
+
 	tfjson "github.com/hashicorp/terraform-json"
 
 	"github.com/busser/tfautomv/internal/flatmap"
@@ -29,15 +32,176 @@ type Resource struct {
 	// The resource's address in Terraform's state.
 	Address string
 
+	// The parts of the resource's address: the module containing it, empty
+	// for the root module, whether it is a managed resource or a data source,
+	// its name, and its instance key if it uses count or for_each.
+	ModuleAddress string
+	Mode          tfjson.ResourceMode
+	Name          string
+	Index         interface{}
+
+	// The source address of the provider managing the resource, like
+	// "registry.terraform.io/hashicorp/aws".
+	ProviderName string
+
+	// The provider configuration managing the resource, like
+	// `provider["registry.terraform.io/hashicorp/aws"].west`, which tells
+	// apart aliases of the same provider. Empty if unknown.
+	ProviderConfig string
+
+	// Replaced is true when Terraform plans to destroy the resource and create
+	// it again at the same address. The address is then still in use and
+	// still declared, so no other resource can move there and the resource
+	// cannot move elsewhere.
+	Replaced bool
+
 	// The resource's attributes, flattened.
 	Attributes map[string]interface{}
+
+	// The resource's attributes whose values are only known after apply,
+	// flattened. Only resources planned for creation have such attributes.
+	Unknown map[string]bool
+
+	// The resource's attributes marked as sensitive, flattened. An attribute
+	// is also sensitive if an attribute containing it is; use IsSensitive.
+	Sensitive map[string]bool
+
+	// AllSensitive is true when the whole resource is marked as sensitive, so
+	// that all of its attributes are.
+	AllSensitive bool
+
+	// The resource's attributes set in its Terraform configuration, as
+	// opposed to computed by the provider. Only resources planned for
+	// creation have a configuration. Nil if the plan does not include it.
+	Configured map[string]bool
+
+	// The addresses, without instance keys, of the resources that depend on
+	// this one: in the configuration for resources planned for creation, and
+	// in the state for resources planned for destruction.
+	Dependents []string
+
+	// The working directory whose state contains the resource. Empty unless
+	// the analysis covers several working directories.
+	Workdir string
+}
+
+// CreatedByModule indexes resources planned for creation by the address of
+// the module containing them. The root module's address is empty. Resources
+// in each module are in the same order as in Created.
+func (a *Analysis) CreatedByModule() map[string][]*Resource {
+	return byModule(a.Created())
+}
+
+// DestroyedByModule indexes resources planned for destruction by the address
+// of the module containing them. The root module's address is empty.
+// Resources in each module are in the same order as in Destroyed.
+func (a *Analysis) DestroyedByModule() map[string][]*Resource {
+	return byModule(a.Destroyed())
+}
+
+func byModule(resources []*Resource) map[string][]*Resource {
+	modules := make(map[string][]*Resource)
+	for _, r := range resources {
+		modules[r.ModuleAddress] = append(modules[r.ModuleAddress], r)
+	}
+	return modules
+}
+
+// ResourceAddress returns the address of the resource, without its instance
+// key. All instances of a resource created with count or for_each share this
+// address.
+func (r *Resource) ResourceAddress() string {
+	addr := r.Type + "." + r.Name
+	if r.Mode == tfjson.DataResourceMode {
+		addr = "data." + addr
+	}
+	if r.ModuleAddress != "" {
+		addr = r.ModuleAddress + "." + addr
+	}
+	return addr
+}
+
+// IsSensitive returns whether the value of the attribute should be kept
+// secret.
+func (r *Resource) IsSensitive(attr string) bool {
+	return r.AllSensitive || flatmap.IsMarked(r.Sensitive, attr)
+}
+
+// AnalysisOptions configure how AnalysisFromPlan compares resources.
+type AnalysisOptions struct {
+	// By default, attributes whose values are only known after apply are
+	// reported but do not prevent resources from matching. When StrictUnknowns
+	// is true, comparisons with more unknown attributes than matching
+	// attributes are inconclusive instead.
+	StrictUnknowns bool
+
+	// By default, all attributes of resources planned for creation are
+	// compared, including those computed by the provider. When ConfiguredOnly
+	// is true, only attributes set in the Terraform configuration are
+	// compared. This has no effect on plans without configuration.
+	ConfiguredOnly bool
+
+	// By default, resources managed by different providers do not match,
+	// since moving them between providers would corrupt the state. When
+	// AllowCrossProvider is true, they can match.
+	AllowCrossProvider bool
+
+	// StateProviders lists the provider configuration managing each resource
+	// in each working directory's state, as returned by
+	// terraform.StateProviders. Plans do not say which provider configuration
+	// manages a resource planned for destruction, so without it, resources
+	// managed by different aliases of the same provider can match.
+	StateProviders map[string]map[string]string
+
+	// MinMatching is the least evidence resources must have in common to
+	// match. Comparisons with less evidence are inconclusive. By default, no
+	// evidence is required, so resources without attributes match.
+	MinMatching Threshold
+
+	// MinMatchingByType overrides MinMatching for specific resource types.
+	// Fields left at zero use the value set in MinMatching, unless they are
+	// marked as set.
+	MinMatchingByType map[string]Threshold
+
+	// TypeEquivalences lists the types of resources that can be moved to
+	// another type. Resources planned for creation are compared with
+	// resources of the same type and of any equivalent type planned for
+	// destruction. If several equivalences have the same types, the last one
+	// is used.
+	TypeEquivalences []TypeEquivalence
+}
+
+// A Threshold is the least evidence two resources must have in common for
+// their comparison to be conclusive.
+type Threshold struct {
+	// Lowest number of attributes that must match.
+	Count int
+
+	// Lowest fraction of compared attributes that must match, between 0 and
+	// 1. Attributes whose differences are ignored or whose values are unknown
+	// count as compared.
+	Ratio float64
+
+	// CountSet and RatioSet mark Count and Ratio as set even when they are
+	// zero, so that a threshold for a specific type can lower the threshold
+	// for all types.
+	CountSet bool
+	RatioSet bool
 }
 
 // AnalysisFromPlan reads the contents of plan and compares resources planned
 // for creation with resources planned for destruction of the same type.
 // Resources may match, depending on their attributes' values and the rules
-// passed to AnalysisFromPlan.
-func AnalysisFromPlan(plan *tfjson.Plan, rules []ignore.Rule) (*Analysis, error) {
+// and options passed to AnalysisFromPlan.
+func AnalysisFromPlan(plan *tfjson.Plan, rules []ignore.Rule, opts AnalysisOptions) (*Analysis, error) {
+	return AnalysisFromPlans(map[string]*tfjson.Plan{"": plan}, rules, opts)
+}
+
+// AnalysisFromPlans is like AnalysisFromPlan, but compares resources planned
+// for creation in any of the plans with resources planned for destruction in
+// any of the plans. This allows finding resources that moved from one state to
+// another. Plans are indexed by the working directory they were made in.
+func AnalysisFromPlans(plans map[string]*tfjson.Plan, rules []ignore.Rule, opts AnalysisOptions) (*Analysis, error) {
 
 	// We start with some preprocessing. We identify all ressources planned for
 	// creation, or deletion, or both and ignore the rest. We flatten each of
@@ -48,6 +212,181 @@ func AnalysisFromPlan(plan *tfjson.Plan, rules []ignore.Rule) (*Analysis, error)
 	createdByType := make(map[string][]*Resource)
 	destroyedByType := make(map[string][]*Resource)
 
+	for workdir, plan := range plans {
+		if err := indexResources(plan, workdir, opts.StateProviders[workdir], createdByType, destroyedByType); err != nil {
+			return nil, err
+		}
+	}
+
+	// Plans are indexed by a map, so we sort resources to always produce the
+	// same analysis.
+	for _, byType := range []map[string][]*Resource{createdByType, destroyedByType} {
+		for _, resources := range byType {
+			sort.SliceStable(resources, func(i, j int) bool {
+				return resources[i].Workdir < resources[j].Workdir
+			})
+		}
+	}
+
+	// Then, we compare all resources planned for creation will all resources
+	// planned for destruction of the same type.
+
+	equivalences := opts.typeEquivalences()
+
+	comparisons := make(map[*Resource][]Comparison)
+	for typ := range createdByType {
+		for _, created := range createdByType[typ] {
+			for _, destroyed := range destroyedByType[typ] {
+				// If both resources have the same address in the same state,
+				// then no move is possible. This can happen when a resource
+				// requires changes or has been tainted, for example.
+				if created.Address == destroyed.Address && created.Workdir == destroyed.Workdir {
+					continue
+				}
+
+				comp := Compare(created, destroyed, rules, opts)
+				comparisons[created] = append(comparisons[created], comp)
+				comparisons[destroyed] = append(comparisons[destroyed], comp)
+			}
+
+			for _, eq := range equivalences {
+				if eq.To != typ {
+					continue
+				}
+				for _, destroyed := range destroyedByType[eq.From] {
+					comp := compare(created, destroyed, eq.renames(), rules, opts)
+					comparisons[created] = append(comparisons[created], comp)
+					comparisons[destroyed] = append(comparisons[destroyed], comp)
+				}
+			}
+		}
+	}
+
+	analysis := Analysis{
+		Comparisons:     comparisons,
+		CreatedByType:   createdByType,
+		DestroyedByType: destroyedByType,
+	}
+	analysis.preferSiblings()
+	analysis.preferDependencies()
+
+	return &analysis, nil
+}
+
+// check marks the comparison as inconclusive if it does not meet the
+// requirements set by the options.
+func (opts AnalysisOptions) check(comp *Comparison) {
+	// The first checks are about whether a move is safe at all, so they apply
+	// even to resources that mismatch. The optimal assignment pairs such
+	// resources too, unless their comparison is inconclusive.
+
+	if comp.Created.Replaced {
+		comp.Inconclusive = fmt.Sprintf("%s is planned for replacement, so it still exists in the state and cannot be moved to", comp.Created.Address)
+		if isIndexShift(comp.Created, comp.Destroyed) {
+			comp.Inconclusive += "; the resource's indices shifted, which for_each instead of count would avoid"
+		}
+		return
+	}
+	if comp.Destroyed.Replaced {
+		comp.Inconclusive = fmt.Sprintf("%s is planned for replacement, so it is still declared in the configuration and cannot be moved from", comp.Destroyed.Address)
+		return
+	}
+
+	// Resources of equivalent types can be managed by different providers,
+	// like null_resource and terraform_data. Their provider configurations
+	// then differ too, so they are only compared for resources managed by
+	// the same provider.
+	sameType := comp.Created.Type == comp.Destroyed.Type
+	sameProvider := comp.Created.ProviderName == comp.Destroyed.ProviderName
+	if sameType && !opts.AllowCrossProvider && !sameProvider {
+		comp.Inconclusive = fmt.Sprintf("resources are managed by different providers: %q and %q", comp.Created.ProviderName, comp.Destroyed.ProviderName)
+		return
+	}
+	created, destroyed := comp.Created.ProviderConfig, comp.Destroyed.ProviderConfig
+	if sameProvider && !opts.AllowCrossProvider && created != "" && destroyed != "" && created != destroyed {
+		comp.Inconclusive = fmt.Sprintf("resources are managed by different provider configurations: %s and %s", created, destroyed)
+		return
+	}
+
+	// Resources that mismatch do not need another reason not to match.
+	if len(comp.MismatchingAttributes) > 0 {
+		return
+	}
+
+	min := opts.minMatching(comp.Created.Type)
+	matching := len(comp.MatchingAttributes)
+	compared := matching + len(comp.IgnoredAttributes) + len(comp.UnknownAttributes)
+
+	switch {
+	case matching < min.Count:
+		comp.Inconclusive = fmt.Sprintf("only %d attributes match, but at least %d must match", matching, min.Count)
+	case min.Ratio > 0 && (compared == 0 || float64(matching)/float64(compared) < min.Ratio):
+		comp.Inconclusive = fmt.Sprintf("only %d of %d attributes match, but at least %g%% must match", matching, compared, min.Ratio*100)
+	case opts.StrictUnknowns && len(comp.UnknownAttributes) > matching:
+		comp.Inconclusive = fmt.Sprintf("%d attributes are only known after apply, but only %d attributes match", len(comp.UnknownAttributes), matching)
+	}
+}
+
+// typeEquivalences returns the type equivalences to use, with a single
+// equivalence for each pair of types. Comparing the same resources twice
+// would make each of them match twice, so neither would be moved.
+func (opts AnalysisOptions) typeEquivalences() []TypeEquivalence {
+	type types struct {
+		from, to string
+	}
+
+	index := make(map[types]int, len(opts.TypeEquivalences))
+	var equivalences []TypeEquivalence
+	for _, eq := range opts.TypeEquivalences {
+		t := types{eq.From, eq.To}
+		if i, ok := index[t]; ok {
+			equivalences[i] = eq
+			continue
+		}
+		index[t] = len(equivalences)
+		equivalences = append(equivalences, eq)
+	}
+
+	return equivalences
+}
+
+// minMatching returns the threshold that applies to resources of type typ.
+func (opts AnalysisOptions) minMatching(typ string) Threshold {
+	min := opts.MinMatching
+	if t, ok := opts.MinMatchingByType[typ]; ok {
+		if t.Count != 0 || t.CountSet {
+			min.Count = t.Count
+		}
+		if t.Ratio != 0 || t.RatioSet {
+			min.Ratio = t.Ratio
+		}
+	}
+	return min
+}
+
+// newResource returns a resource with the identity described by the change,
+// but none of its attributes.
+func newResource(c *tfjson.ResourceChange, workdir string) Resource {
+	return Resource{
+		Type:          c.Type,
+		Address:       c.Address,
+		ModuleAddress: c.ModuleAddress,
+		Mode:          c.Mode,
+		Name:          c.Name,
+		Index:         c.Index,
+		ProviderName:  c.ProviderName,
+		Replaced:      c.Change.Actions.Replace(),
+		Workdir:       workdir,
+	}
+}
+
+// indexResources adds the resources the plan creates or destroys to the
+// indexes, by type. stateProviders is the provider configuration of each
+// resource in the state, if known.
+func indexResources(plan *tfjson.Plan, workdir string, stateProviders map[string]string, createdByType, destroyedByType map[string][]*Resource) error {
+	createdDependents := configDependents(plan.Config)
+	destroyedDependents := stateDependents(plan.PriorState)
+
 	for _, c := range plan.ResourceChanges {
 		isCreated := slices.Contains(c.Change.Actions, tfjson.ActionCreate)
 		isDestroyed := slices.Contains(c.Change.Actions, tfjson.ActionDelete)
@@ -59,60 +398,60 @@ func AnalysisFromPlan(plan *tfjson.Plan, rules []ignore.Rule) (*Analysis, error)
 		if isCreated {
 			flatAttributes, err := flatmap.Flatten(c.Change.After)
 			if err != nil {
-				return nil, err
+				return err
 			}
 
-			r := Resource{
-				Type:       c.Type,
-				Address:    c.Address,
-				Attributes: flatAttributes,
+			unknown, err := flatmap.Marks(c.Change.AfterUnknown)
+			if err != nil {
+				return err
+			}
+
+			sensitive, err := flatmap.Marks(c.Change.AfterSensitive)
+			if err != nil {
+				return err
 			}
 
+			attrs := make([]string, 0, len(flatAttributes)+len(unknown))
+			for attr := range flatAttributes {
+				attrs = append(attrs, attr)
+			}
+			for attr := range unknown {
+				attrs = append(attrs, attr)
+			}
+
+			r := newResource(c, workdir)
+			r.Attributes = flatAttributes
+			r.Unknown = unknown
+			r.Sensitive = sensitive
+			r.AllSensitive = flatmap.AllMarked(c.Change.AfterSensitive)
+			r.Configured = configuredAttributes(plan.Config, c, attrs)
+			r.ProviderConfig = providerConfig(plan.Config, c)
+			r.Dependents = createdDependents[r.configAddress()]
+
 			createdByType[r.Type] = append(createdByType[r.Type], &r)
 		}
 
 		if isDestroyed {
 			flatAttributes, err := flatmap.Flatten(c.Change.Before)
 			if err != nil {
-				return nil, err
+				return err
 			}
 
-			r := Resource{
-				Type:       c.Type,
-				Address:    c.Address,
-				Attributes: flatAttributes,
+			sensitive, err := flatmap.Marks(c.Change.BeforeSensitive)
+			if err != nil {
+				return err
 			}
 
-			destroyedByType[r.Type] = append(destroyedByType[r.Type], &r)
-		}
-	}
-
-	// Then, we compare all resources planned for creation will all resources
-	// planned for destruction of the same type.
-
-	comparisons := make(map[*Resource][]Comparison)
-	for typ := range createdByType {
-		for _, created := range createdByType[typ] {
-			for _, destroyed := range destroyedByType[typ] {
-				// If both resources have the same address, then no move is
-				// possible. This can happen when a resource requires changes or
-				// has been tainted, for example.
-				if created.Address == destroyed.Address {
-					continue
-				}
+			r := newResource(c, workdir)
+			r.Attributes = flatAttributes
+			r.Sensitive = sensitive
+			r.AllSensitive = flatmap.AllMarked(c.Change.BeforeSensitive)
+			r.ProviderConfig = stateProviders[r.ResourceAddress()]
+			r.Dependents = destroyedDependents[r.configAddress()]
 
-				comp := Compare(created, destroyed, rules)
-				comparisons[created] = append(comparisons[created], comp)
-				comparisons[destroyed] = append(comparisons[destroyed], comp)
-			}
+			destroyedByType[r.Type] = append(destroyedByType[r.Type], &r)
 		}
 	}
 
-	analysis := Analysis{
-		Comparisons:     comparisons,
-		CreatedByType:   createdByType,
-		DestroyedByType: destroyedByType,
-	}
-
-	return &analysis, nil
+	return nil
 }
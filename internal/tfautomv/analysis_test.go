@@ -1,12 +1,15 @@
 package tfautomv
 
 import (
+	"fmt"
+	"reflect"
 	"sort"
 	"testing"
 
 	tfjson "github.com/hashicorp/terraform-json"
 
 	"github.com/busser/tfautomv/internal/slices"
+	"github.com/busser/tfautomv/internal/terraform"
 )
 
 type dummyResource struct {
@@ -121,7 +124,7 @@ func TestAnalysisFromPlan(t *testing.T) {
 	for _, tc := range tt {
 		plan := dummyPlan(t, tc.createdResources, tc.destroyedResources)
 
-		actual, err := AnalysisFromPlan(plan, nil)
+		actual, err := AnalysisFromPlan(plan, nil, AnalysisOptions{})
 		if err != nil {
 			t.Fatalf("AnalysisFromPlan(): unexpected error: %v", err)
 		}
@@ -160,3 +163,591 @@ func TestAnalysisFromPlan(t *testing.T) {
 		}
 	}
 }
+
+func TestAnalysisFromPlanUnknownAttributes(t *testing.T) {
+	plan := &tfjson.Plan{
+		ResourceChanges: []*tfjson.ResourceChange{
+			{
+				Address: "random_pet.refactored",
+				Type:    "random_pet",
+				Change: &tfjson.Change{
+					Actions: []tfjson.Action{tfjson.ActionCreate},
+					After: map[string]interface{}{
+						"length": 2,
+					},
+					AfterUnknown: map[string]interface{}{
+						"id":      true,
+						"keepers": map[string]interface{}{"uuid": true},
+						"tags":    []interface{}{false, true},
+					},
+				},
+			},
+			{
+				Address: "random_pet.original",
+				Type:    "random_pet",
+				Change: &tfjson.Change{
+					Actions: []tfjson.Action{tfjson.ActionDelete},
+					Before: map[string]interface{}{
+						"id":     "brave-cat",
+						"length": 2,
+					},
+				},
+			},
+		},
+	}
+
+	tt := []struct {
+		name      string
+		opts      AnalysisOptions
+		wantMatch bool
+	}{
+		{
+			name:      "default",
+			opts:      AnalysisOptions{},
+			wantMatch: true,
+		},
+		{
+			name:      "strict",
+			opts:      AnalysisOptions{StrictUnknowns: true},
+			wantMatch: false,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			analysis, err := AnalysisFromPlan(plan, nil, tc.opts)
+			if err != nil {
+				t.Fatalf("AnalysisFromPlan(): unexpected error: %v", err)
+			}
+
+			comp := analysis.Comparisons[analysis.CreatedByType["random_pet"][0]][0]
+
+			unknown := append([]string(nil), comp.UnknownAttributes...)
+			sort.Strings(unknown)
+			wantUnknown := []string{"id", "keepers.uuid", "tags.1"}
+			if !slices.Equal(unknown, wantUnknown) {
+				t.Errorf("UnknownAttributes = %q, want %q", unknown, wantUnknown)
+			}
+
+			if comp.IsMatch() != tc.wantMatch {
+				t.Errorf("IsMatch() = %t, want %t", comp.IsMatch(), tc.wantMatch)
+			}
+			if tc.wantMatch != (comp.Inconclusive == "") {
+				t.Errorf("Inconclusive = %q, want it set only when resources do not match", comp.Inconclusive)
+			}
+		})
+	}
+}
+
+func TestAnalysisFromPlanSensitiveAttributes(t *testing.T) {
+	plan := &tfjson.Plan{
+		ResourceChanges: []*tfjson.ResourceChange{
+			{
+				Address: "random_password.refactored",
+				Type:    "random_password",
+				Change: &tfjson.Change{
+					Actions:        []tfjson.Action{tfjson.ActionCreate},
+					After:          map[string]interface{}{"length": 16, "keepers": map[string]interface{}{"token": "a"}},
+					AfterSensitive: map[string]interface{}{"keepers": true},
+				},
+			},
+			{
+				Address: "random_password.original",
+				Type:    "random_password",
+				Change: &tfjson.Change{
+					Actions:         []tfjson.Action{tfjson.ActionDelete},
+					Before:          map[string]interface{}{"length": 16, "result": "hunter2", "keepers": map[string]interface{}{"token": "a"}},
+					BeforeSensitive: map[string]interface{}{"result": true, "keepers": true},
+				},
+			},
+			{
+				Address: "tls_private_key.refactored",
+				Type:    "tls_private_key",
+				Change: &tfjson.Change{
+					Actions:        []tfjson.Action{tfjson.ActionCreate},
+					After:          map[string]interface{}{"algorithm": "RSA"},
+					AfterSensitive: true,
+				},
+			},
+			{
+				Address: "tls_private_key.original",
+				Type:    "tls_private_key",
+				Change: &tfjson.Change{
+					Actions:         []tfjson.Action{tfjson.ActionDelete},
+					Before:          map[string]interface{}{"algorithm": "RSA", "private_key_pem": "secret"},
+					BeforeSensitive: true,
+				},
+			},
+		},
+	}
+
+	analysis, err := AnalysisFromPlan(plan, nil, AnalysisOptions{})
+	if err != nil {
+		t.Fatalf("AnalysisFromPlan(): unexpected error: %v", err)
+	}
+
+	created := analysis.CreatedByType["random_password"][0]
+	destroyed := analysis.DestroyedByType["random_password"][0]
+	createdKey := analysis.CreatedByType["tls_private_key"][0]
+	destroyedKey := analysis.DestroyedByType["tls_private_key"][0]
+
+	for _, tc := range []struct {
+		resource *Resource
+		attr     string
+		want     bool
+	}{
+		{created, "length", false},
+		{created, "keepers.token", true},
+		{destroyed, "result", true},
+		{destroyed, "keepers.token", true},
+		{destroyed, "length", false},
+		{createdKey, "algorithm", true},
+		{destroyedKey, "algorithm", true},
+		{destroyedKey, "private_key_pem", true},
+	} {
+		if got := tc.resource.IsSensitive(tc.attr); got != tc.want {
+			t.Errorf("%s: IsSensitive(%q) = %t, want %t", tc.resource.Address, tc.attr, got, tc.want)
+		}
+	}
+
+	// Sensitive attributes are still compared.
+	if comp := analysis.Comparisons[created][0]; !comp.IsMatch() {
+		t.Errorf("resources should match, but mismatching attributes are %q", comp.MismatchingAttributes)
+	}
+}
+
+func TestAnalysisFromPlanMinMatching(t *testing.T) {
+	plan := dummyPlanWithAttributes(t,
+		[]dummyResourceWithAttributes{
+			{"random_pet.alpha", "random_pet", map[string]interface{}{"length": 2}},
+			{"null_resource.alpha", "null_resource", map[string]interface{}{}},
+		},
+		[]dummyResourceWithAttributes{
+			{"random_pet.first", "random_pet", map[string]interface{}{"length": 2}},
+			{"null_resource.first", "null_resource", map[string]interface{}{}},
+		},
+	)
+
+	tt := []struct {
+		name      string
+		opts      AnalysisOptions
+		wantMatch map[string]bool
+	}{
+		{
+			name: "default",
+			opts: AnalysisOptions{},
+			wantMatch: map[string]bool{
+				"random_pet":    true,
+				"null_resource": true,
+			},
+		},
+		{
+			name: "global count",
+			opts: AnalysisOptions{
+				MinMatching: Threshold{Count: 1},
+			},
+			wantMatch: map[string]bool{
+				"random_pet":    true,
+				"null_resource": false,
+			},
+		},
+		{
+			name: "count by type",
+			opts: AnalysisOptions{
+				MinMatching: Threshold{Count: 1},
+				MinMatchingByType: map[string]Threshold{
+					"random_pet": {Count: 2},
+				},
+			},
+			wantMatch: map[string]bool{
+				"random_pet":    false,
+				"null_resource": false,
+			},
+		},
+		{
+			name: "count by type below global count",
+			opts: AnalysisOptions{
+				MinMatching: Threshold{Count: 2},
+				MinMatchingByType: map[string]Threshold{
+					"null_resource": {Count: 0, CountSet: true},
+				},
+			},
+			wantMatch: map[string]bool{
+				"random_pet":    false,
+				"null_resource": true,
+			},
+		},
+		{
+			name: "ratio by type below global ratio",
+			opts: AnalysisOptions{
+				MinMatching: Threshold{Ratio: 0.5},
+				MinMatchingByType: map[string]Threshold{
+					"null_resource": {Ratio: 0, RatioSet: true},
+				},
+			},
+			wantMatch: map[string]bool{
+				"random_pet":    true,
+				"null_resource": true,
+			},
+		},
+		{
+			name: "ratio",
+			opts: AnalysisOptions{
+				MinMatching: Threshold{Ratio: 0.5},
+			},
+			wantMatch: map[string]bool{
+				"random_pet":    true,
+				"null_resource": false,
+			},
+		},
+		{
+			name: "ratio by type falls back to global count",
+			opts: AnalysisOptions{
+				MinMatching: Threshold{Count: 2},
+				MinMatchingByType: map[string]Threshold{
+					"random_pet": {Ratio: 0.5},
+				},
+			},
+			wantMatch: map[string]bool{
+				"random_pet":    false,
+				"null_resource": false,
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			analysis, err := AnalysisFromPlan(plan, nil, tc.opts)
+			if err != nil {
+				t.Fatalf("AnalysisFromPlan(): unexpected error: %v", err)
+			}
+
+			for typ, want := range tc.wantMatch {
+				comp := analysis.Comparisons[analysis.CreatedByType[typ][0]][0]
+				if comp.IsMatch() != want {
+					t.Errorf("%s: IsMatch() = %t, want %t", typ, comp.IsMatch(), want)
+				}
+				if want != (comp.Inconclusive == "") {
+					t.Errorf("%s: Inconclusive = %q, want it set only when resources do not match", typ, comp.Inconclusive)
+				}
+			}
+		})
+	}
+}
+
+func TestAnalysisFromPlanProviders(t *testing.T) {
+	plan := &tfjson.Plan{
+		ResourceChanges: []*tfjson.ResourceChange{
+			{
+				Address:       `module.storage.aws_s3_bucket.this["logs"]`,
+				ModuleAddress: "module.storage",
+				Mode:          tfjson.ManagedResourceMode,
+				Type:          "aws_s3_bucket",
+				Name:          "this",
+				Index:         "logs",
+				ProviderName:  "registry.terraform.io/hashicorp/aws",
+				Change: &tfjson.Change{
+					Actions: []tfjson.Action{tfjson.ActionCreate},
+					After:   map[string]interface{}{"bucket": "logs"},
+				},
+			},
+			{
+				Address:      "aws_s3_bucket.logs",
+				Mode:         tfjson.ManagedResourceMode,
+				Type:         "aws_s3_bucket",
+				Name:         "logs",
+				ProviderName: "registry.example.com/acme/aws",
+				Change: &tfjson.Change{
+					Actions: []tfjson.Action{tfjson.ActionDelete},
+					Before:  map[string]interface{}{"bucket": "logs"},
+				},
+			},
+		},
+	}
+
+	tt := []struct {
+		name      string
+		opts      AnalysisOptions
+		wantMatch bool
+	}{
+		{
+			name:      "default",
+			opts:      AnalysisOptions{},
+			wantMatch: false,
+		},
+		{
+			name:      "cross provider allowed",
+			opts:      AnalysisOptions{AllowCrossProvider: true},
+			wantMatch: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			analysis, err := AnalysisFromPlan(plan, nil, tc.opts)
+			if err != nil {
+				t.Fatalf("AnalysisFromPlan(): unexpected error: %v", err)
+			}
+
+			created := analysis.CreatedByType["aws_s3_bucket"][0]
+			want := Resource{
+				Type:          "aws_s3_bucket",
+				Address:       `module.storage.aws_s3_bucket.this["logs"]`,
+				ModuleAddress: "module.storage",
+				Mode:          tfjson.ManagedResourceMode,
+				Name:          "this",
+				Index:         "logs",
+				ProviderName:  "registry.terraform.io/hashicorp/aws",
+			}
+			got := Resource{
+				Type:          created.Type,
+				Address:       created.Address,
+				ModuleAddress: created.ModuleAddress,
+				Mode:          created.Mode,
+				Name:          created.Name,
+				Index:         created.Index,
+				ProviderName:  created.ProviderName,
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("created resource = %#v, want %#v", got, want)
+			}
+
+			byModule := analysis.CreatedByModule()
+			if len(byModule["module.storage"]) != 1 {
+				t.Errorf("CreatedByModule() = %v, want one resource in module.storage", byModule)
+			}
+
+			comp := analysis.Comparisons[created][0]
+			if comp.IsMatch() != tc.wantMatch {
+				t.Errorf("IsMatch() = %t, want %t", comp.IsMatch(), tc.wantMatch)
+			}
+		})
+	}
+}
+
+func TestAnalysisFromPlanProviderAliases(t *testing.T) {
+	const aws = "registry.terraform.io/hashicorp/aws"
+
+	newPlan := func(createdType, destroyedType string) *tfjson.Plan {
+		return &tfjson.Plan{
+			ResourceChanges: []*tfjson.ResourceChange{
+				{
+					Address:      createdType + ".new",
+					Mode:         tfjson.ManagedResourceMode,
+					Type:         createdType,
+					Name:         "new",
+					ProviderName: aws,
+					Change: &tfjson.Change{
+						Actions: []tfjson.Action{tfjson.ActionCreate},
+						After:   map[string]interface{}{"name": "logs"},
+					},
+				},
+				{
+					Address:      destroyedType + ".old",
+					Mode:         tfjson.ManagedResourceMode,
+					Type:         destroyedType,
+					Name:         "old",
+					ProviderName: aws,
+					Change: &tfjson.Change{
+						Actions: []tfjson.Action{tfjson.ActionDelete},
+						Before:  map[string]interface{}{"name": "logs"},
+					},
+				},
+			},
+			Config: &tfjson.Config{
+				RootModule: &tfjson.ConfigModule{
+					Resources: []*tfjson.ConfigResource{
+						{
+							Address:           createdType + ".new",
+							Mode:              tfjson.ManagedResourceMode,
+							Type:              createdType,
+							Name:              "new",
+							ProviderConfigKey: "aws.west",
+						},
+					},
+				},
+			},
+		}
+	}
+
+	tt := []struct {
+		name           string
+		destroyedType  string
+		stateProviders map[string]string
+		allowCross     bool
+		wantMatch      bool
+	}{
+		{
+			name:           "same alias",
+			stateProviders: map[string]string{"aws_lb.old": `provider["registry.terraform.io/hashicorp/aws"].west`},
+			wantMatch:      true,
+		},
+		{
+			name:           "different alias",
+			stateProviders: map[string]string{"aws_lb.old": `provider["registry.terraform.io/hashicorp/aws"].east`},
+			wantMatch:      false,
+		},
+		{
+			name:           "alias and default",
+			stateProviders: map[string]string{"aws_lb.old": `provider["registry.terraform.io/hashicorp/aws"]`},
+			wantMatch:      false,
+		},
+		{
+			name:           "different alias allowed",
+			stateProviders: map[string]string{"aws_lb.old": `provider["registry.terraform.io/hashicorp/aws"].east`},
+			allowCross:     true,
+			wantMatch:      true,
+		},
+		{
+			name:      "state not read",
+			wantMatch: true,
+		},
+		{
+			name:           "different alias between equivalent types",
+			destroyedType:  "aws_alb",
+			stateProviders: map[string]string{"aws_alb.old": `provider["registry.terraform.io/hashicorp/aws"].east`},
+			wantMatch:      false,
+		},
+		{
+			name:           "same alias between equivalent types",
+			destroyedType:  "aws_alb",
+			stateProviders: map[string]string{"aws_alb.old": `provider["registry.terraform.io/hashicorp/aws"].west`},
+			wantMatch:      true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			destroyedType := tc.destroyedType
+			if destroyedType == "" {
+				destroyedType = "aws_lb"
+			}
+			opts := AnalysisOptions{
+				AllowCrossProvider: tc.allowCross,
+				StateProviders:     map[string]map[string]string{"": tc.stateProviders},
+				TypeEquivalences:   []TypeEquivalence{{From: "aws_alb", To: "aws_lb"}},
+			}
+			analysis, err := AnalysisFromPlan(newPlan("aws_lb", destroyedType), nil, opts)
+			if err != nil {
+				t.Fatalf("AnalysisFromPlan(): unexpected error: %v", err)
+			}
+
+			created := analysis.CreatedByType["aws_lb"][0]
+			if want := `provider["registry.terraform.io/hashicorp/aws"].west`; created.ProviderConfig != want {
+				t.Errorf("ProviderConfig = %q, want %q", created.ProviderConfig, want)
+			}
+
+			comp := analysis.Comparisons[created][0]
+			if comp.IsMatch() != tc.wantMatch {
+				t.Errorf("IsMatch() = %t, want %t (inconclusive: %q)", comp.IsMatch(), tc.wantMatch, comp.Inconclusive)
+			}
+		})
+	}
+}
+
+func TestAnalysisFromPlanIndexShifts(t *testing.T) {
+	pet := func(name string, index interface{}, actions tfjson.Actions, before, after string) *tfjson.ResourceChange {
+		c := &tfjson.ResourceChange{
+			Address:      fmt.Sprintf("random_pet.%s[%#v]", name, index),
+			Mode:         tfjson.ManagedResourceMode,
+			Type:         "random_pet",
+			Name:         name,
+			Index:        index,
+			ProviderName: "registry.terraform.io/hashicorp/random",
+			Change:       &tfjson.Change{Actions: actions},
+		}
+		if before != "" {
+			c.Change.Before = map[string]interface{}{"prefix": before}
+		}
+		if after != "" {
+			c.Change.After = map[string]interface{}{"prefix": after}
+		}
+		return c
+	}
+	replace := tfjson.Actions{tfjson.ActionDelete, tfjson.ActionCreate}
+	create := tfjson.Actions{tfjson.ActionCreate}
+	destroy := tfjson.Actions{tfjson.ActionDelete}
+
+	tt := []struct {
+		name       string
+		changes    []*tfjson.ResourceChange
+		wantMoves  []terraform.Move
+		wantShifts []string
+	}{
+		{
+			name: "count to for_each",
+			changes: []*tfjson.ResourceChange{
+				pet("this", 0, destroy, "alpha", ""),
+				pet("this", 1, destroy, "bravo", ""),
+				pet("this", "alpha", create, "", "alpha"),
+				pet("this", "bravo", create, "", "bravo"),
+			},
+			wantMoves: []terraform.Move{
+				{From: "random_pet.this[0]", To: `random_pet.this["alpha"]`},
+				{From: "random_pet.this[1]", To: `random_pet.this["bravo"]`},
+			},
+		},
+		{
+			name: "count to for_each in resources with the same attributes",
+			changes: []*tfjson.ResourceChange{
+				pet("this", 0, destroy, "alpha", ""),
+				pet("that", 0, destroy, "alpha", ""),
+				pet("this", "alpha", create, "", "alpha"),
+				pet("that", "alpha", create, "", "alpha"),
+			},
+			wantMoves: []terraform.Move{
+				{From: "random_pet.that[0]", To: `random_pet.that["alpha"]`},
+				{From: "random_pet.this[0]", To: `random_pet.this["alpha"]`},
+			},
+		},
+		{
+			name: "count to for_each with ambiguous keys",
+			changes: []*tfjson.ResourceChange{
+				pet("this", 0, destroy, "alpha", ""),
+				pet("this", 1, destroy, "alpha", ""),
+				pet("that", 0, destroy, "alpha", ""),
+				pet("this", "first", create, "", "alpha"),
+				pet("this", "second", create, "", "alpha"),
+			},
+			wantMoves: nil,
+		},
+		{
+			name: "replaced resource planned for destruction",
+			changes: []*tfjson.ResourceChange{
+				pet("this", 0, replace, "alpha", "bravo"),
+				pet("that", 0, create, "", "alpha"),
+			},
+			wantMoves: nil,
+		},
+		{
+			name: "first element removed from count",
+			changes: []*tfjson.ResourceChange{
+				pet("this", 0, replace, "alpha", "bravo"),
+				pet("this", 1, replace, "bravo", "charlie"),
+				pet("this", 2, destroy, "charlie", ""),
+			},
+			wantShifts: []string{"random_pet.this"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			plan := &tfjson.Plan{ResourceChanges: tc.changes}
+
+			analysis, err := AnalysisFromPlan(plan, nil, AnalysisOptions{})
+			if err != nil {
+				t.Fatalf("AnalysisFromPlan(): unexpected error: %v", err)
+			}
+
+			moves := MovesFromAnalysis(analysis, MovesOptions{})
+			if !reflect.DeepEqual(moves, tc.wantMoves) {
+				t.Errorf("MovesFromAnalysis() = %#v, want %#v", moves, tc.wantMoves)
+			}
+
+			shifts := analysis.IndexShifts()
+			if !reflect.DeepEqual(shifts, tc.wantShifts) {
+				t.Errorf("IndexShifts() = %#v, want %#v", shifts, tc.wantShifts)
+			}
+		})
+	}
+}
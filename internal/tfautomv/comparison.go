@@ -1,6 +1,11 @@
 package tfautomv
 
-import "github.com/busser/tfautomv/internal/tfautomv/ignore"
+import (
+	"sort"
+	"strings"
+
+	"github.com/busser/tfautomv/internal/tfautomv/ignore"
+)
 
 // A Comparison contains a list matching and mismatching attributes between two
 // resources.
@@ -20,6 +25,39 @@ type Comparison struct {
 	// Attributes that are set in Created and are not set or do not have the
 	// same value in Destroyed.
 	MismatchingAttributes []string
+
+	// Attributes of Created whose values are only known after apply, so they
+	// cannot be compared. They do not count towards the score.
+	UnknownAttributes []string
+
+	// Inconclusive explains why the resources do not match even though none
+	// of their attributes mismatch, or why moving one to the other would be
+	// unsafe. Empty if the comparison is conclusive.
+	Inconclusive string
+
+	// Pinned is true when the user paired the resources, regardless of their
+	// attributes.
+	Pinned bool
+
+	// When the resources have different types, renames maps attributes of
+	// Created to the attributes of Destroyed they were compared with, if
+	// their names differ.
+	renames map[string]string
+}
+
+// DestroyedAttribute returns the name of the attribute of Destroyed that attr,
+// an attribute of Created, was compared with. Names only differ between
+// resources of different types.
+func (c *Comparison) DestroyedAttribute(attr string) string {
+	for to, from := range c.renames {
+		if attr == to {
+			return from
+		}
+		if strings.HasPrefix(attr, to+".") {
+			return from + strings.TrimPrefix(attr, to)
+		}
+	}
+	return attr
 }
 
 // Compare finds which attributes match between two resources: one planned for
@@ -34,18 +72,29 @@ type Comparison struct {
 //
 // Attributes set in destroyed but not in created are ignored. We assume they
 // are set by the Terraform provider, the cloud provider, or an external actor.
-func Compare(created, destroyed *Resource, rules []ignore.Rule) Comparison {
+// Attributes of created that are only known after apply are listed separately.
+//
+// The options can restrict which attributes are compared, and make the
+// comparison inconclusive if the resources have too little in common.
+func Compare(created, destroyed *Resource, rules []ignore.Rule, opts AnalysisOptions) Comparison {
+	return compare(created, destroyed, nil, rules, opts)
+}
+
+// compare is like Compare, but compares attributes of created with attributes
+// of destroyed that have a different name, according to renames.
+func compare(created, destroyed *Resource, renames map[string]string, rules []ignore.Rule, opts AnalysisOptions) Comparison {
 	comp := Comparison{
 		Created:   created,
 		Destroyed: destroyed,
+		renames:   renames,
 	}
 
 	for attr, createdVal := range created.Attributes {
-		if createdVal == nil {
+		if createdVal == nil || !opts.compares(created, attr) {
 			continue
 		}
 
-		destroyedVal, isSet := destroyed.Attributes[attr]
+		destroyedVal, isSet := destroyed.Attributes[comp.DestroyedAttribute(attr)]
 
 		// Match: both values are identical.
 		if isSet && createdVal == destroyedVal {
@@ -73,10 +122,60 @@ func Compare(created, destroyed *Resource, rules []ignore.Rule) Comparison {
 		comp.MismatchingAttributes = append(comp.MismatchingAttributes, attr)
 	}
 
+	for attr := range created.Unknown {
+		if opts.compares(created, attr) {
+			comp.UnknownAttributes = append(comp.UnknownAttributes, attr)
+		}
+	}
+
+	// Attributes are found by iterating over maps, so we sort them to keep
+	// the output of tfautomv the same from one run to the next.
+	sort.Strings(comp.MatchingAttributes)
+	sort.Strings(comp.IgnoredAttributes)
+	sort.Strings(comp.MismatchingAttributes)
+	sort.Strings(comp.UnknownAttributes)
+
+	opts.check(&comp)
+
 	return comp
 }
 
+// compares returns whether the attribute of the resource planned for creation
+// should be compared.
+func (opts AnalysisOptions) compares(created *Resource, attr string) bool {
+	if !opts.ConfiguredOnly || created.Configured == nil {
+		return true
+	}
+	return created.Configured[attr]
+}
+
 func (c *Comparison) IsMatch() bool {
-	// Resources match if none of their attributes mismatch.
-	return len(c.MismatchingAttributes) == 0
+	// Resources match if the user paired them or if none of their attributes
+	// mismatch and the comparison is conclusive.
+	return c.Pinned || (len(c.MismatchingAttributes) == 0 && c.Inconclusive == "")
+}
+
+// Weights of each kind of attribute when computing a comparison's score.
+const (
+	matchingAttributeWeight = 1.0
+	ignoredAttributeWeight  = 0.5
+)
+
+// Score measures how similar the compared resources are, between 0 (nothing in
+// common) and 1 (identical). Matching attributes count fully towards the score,
+// attributes whose differences are ignored count half, and mismatching
+// attributes do not count at all.
+//
+// Consistently with IsMatch, resources with no attributes to compare have a
+// score of 1.
+func (c *Comparison) Score() float64 {
+	total := len(c.MatchingAttributes) + len(c.IgnoredAttributes) + len(c.MismatchingAttributes)
+	if total == 0 {
+		return 1
+	}
+
+	weighted := matchingAttributeWeight*float64(len(c.MatchingAttributes)) +
+		ignoredAttributeWeight*float64(len(c.IgnoredAttributes))
+
+	return weighted / float64(total)
 }
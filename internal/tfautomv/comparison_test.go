@@ -14,9 +14,11 @@ func TestCompare(t *testing.T) {
 		created         *Resource
 		destroyed       *Resource
 		rules           []ignore.Rule
+		opts            AnalysisOptions
 		wantMatching    []string
 		wantIgnored     []string
 		wantMismatching []string
+		wantUnknown     []string
 	}{
 		{
 			name: "without rules",
@@ -84,11 +86,62 @@ func TestCompare(t *testing.T) {
 			wantIgnored:     []string{"c", "i", "j"},
 			wantMismatching: []string{"e", "f", "h"},
 		},
+		{
+			name: "with unknown attributes",
+			created: &Resource{
+				Attributes: map[string]interface{}{
+					"a": "hello",
+				},
+				Unknown: map[string]bool{
+					"id":     true,
+					"tags.b": true,
+				},
+			},
+			destroyed: &Resource{
+				Attributes: map[string]interface{}{
+					"a":      "hello",
+					"id":     "abc123",
+					"tags.b": "foo",
+				},
+			},
+			wantMatching: []string{"a"},
+			wantUnknown:  []string{"id", "tags.b"},
+		},
+		{
+			name: "configured attributes only",
+			created: &Resource{
+				Attributes: map[string]interface{}{
+					"a":   "hello",
+					"b":   123,
+					"arn": "arn:aws:foo",
+				},
+				Unknown: map[string]bool{
+					"id":     true,
+					"tags.b": true,
+				},
+				Configured: map[string]bool{
+					"a":      true,
+					"b":      true,
+					"tags.b": true,
+				},
+			},
+			destroyed: &Resource{
+				Attributes: map[string]interface{}{
+					"a":   "hello",
+					"b":   456,
+					"arn": "arn:aws:bar",
+				},
+			},
+			opts:            AnalysisOptions{ConfiguredOnly: true},
+			wantMatching:    []string{"a"},
+			wantMismatching: []string{"b"},
+			wantUnknown:     []string{"tags.b"},
+		},
 	}
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			actual := Compare(tc.created, tc.destroyed, tc.rules)
+			actual := Compare(tc.created, tc.destroyed, tc.rules, tc.opts)
 			if actual.Created != tc.created {
 				t.Errorf("Compare(): resulting Comparison does not point to created resource")
 			}
@@ -116,6 +169,13 @@ func TestCompare(t *testing.T) {
 				t.Errorf("Compare().MismatchingAttributes = %#v, want %#v",
 					actual.MismatchingAttributes, tc.wantMismatching)
 			}
+
+			sort.Strings(actual.UnknownAttributes)
+			sort.Strings(tc.wantUnknown)
+			if !slices.Equal(actual.UnknownAttributes, tc.wantUnknown) {
+				t.Errorf("Compare().UnknownAttributes = %#v, want %#v",
+					actual.UnknownAttributes, tc.wantUnknown)
+			}
 		})
 	}
 }
@@ -139,6 +199,14 @@ func TestIsMatch(t *testing.T) {
 			},
 			want: false,
 		},
+		{
+			comp: Comparison{
+				MatchingAttributes: []string{"a"},
+				UnknownAttributes:  []string{"b", "c"},
+				Inconclusive:       "too many unknown attributes",
+			},
+			want: false,
+		},
 	}
 
 	for _, tc := range tt {
@@ -149,3 +217,49 @@ func TestIsMatch(t *testing.T) {
 		}
 	}
 }
+
+func TestScore(t *testing.T) {
+	tt := []struct {
+		comp Comparison
+		want float64
+	}{
+		{
+			comp: Comparison{},
+			want: 1,
+		},
+		{
+			comp: Comparison{
+				MatchingAttributes: []string{"a", "b"},
+			},
+			want: 1,
+		},
+		{
+			comp: Comparison{
+				MatchingAttributes: []string{"a"},
+				IgnoredAttributes:  []string{"b"},
+			},
+			want: 0.75,
+		},
+		{
+			comp: Comparison{
+				MatchingAttributes:    []string{"a", "b", "c"},
+				MismatchingAttributes: []string{"d"},
+			},
+			want: 0.75,
+		},
+		{
+			comp: Comparison{
+				MismatchingAttributes: []string{"a", "b"},
+			},
+			want: 0,
+		},
+	}
+
+	for _, tc := range tt {
+		actual := tc.comp.Score()
+		if actual != tc.want {
+			t.Errorf("Score() = %v, want %v when MatchingAttributes = %#v, IgnoredAttributes = %#v and MismatchingAttributes = %#v",
+				actual, tc.want, tc.comp.MatchingAttributes, tc.comp.IgnoredAttributes, tc.comp.MismatchingAttributes)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package tfautomv
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// configuredAttributes returns which of the attributes are set in the
+// resource's configuration. It returns nil if the plan does not include the
+// resource's configuration.
+func configuredAttributes(config *tfjson.Config, rc *tfjson.ResourceChange, attrs []string) map[string]bool {
+	res := configResource(config, rc)
+	if res == nil {
+		return nil
+	}
+
+	configured := make(map[string]bool, len(attrs))
+	for _, attr := range attrs {
+		if isConfigured(res.Expressions, attr) {
+			configured[attr] = true
+		}
+	}
+
+	return configured
+}
+
+// providerConfig returns the provider configuration managing the resource,
+// in the format of terraform.StateProviders. It returns an empty string if
+// the plan does not include the resource's configuration.
+func providerConfig(config *tfjson.Config, rc *tfjson.ResourceChange) string {
+	res := configResource(config, rc)
+	if res == nil || res.ProviderConfigKey == "" || rc.ProviderName == "" {
+		return ""
+	}
+
+	// Keys look like "aws.west", or "module.storage:aws.west" for providers
+	// configured inside a module.
+	key := res.ProviderConfigKey
+	if i := strings.LastIndexByte(key, ':'); i >= 0 {
+		key = key[i+1:]
+	}
+
+	provider := fmt.Sprintf("provider[%q]", rc.ProviderName)
+	if _, alias, ok := strings.Cut(key, "."); ok {
+		provider += "." + alias
+	}
+	return provider
+}
+
+// ProvidersWithSeveralConfigs returns the providers configured more than
+// once in the plan's configuration, for example with aliases, sorted by name.
+// Without the state, tfautomv cannot tell which of those configurations
+// manages a resource planned for destruction.
+func ProvidersWithSeveralConfigs(plan *tfjson.Plan) []string {
+	if plan == nil || plan.Config == nil {
+		return nil
+	}
+
+	configs := make(map[string]int)
+	for _, pc := range plan.Config.ProviderConfigs {
+		name := pc.FullName
+		if name == "" {
+			name = pc.Name
+		}
+		configs[name]++
+	}
+
+	var providers []string
+	for name, n := range configs {
+		if n > 1 {
+			providers = append(providers, name)
+		}
+	}
+	sort.Strings(providers)
+
+	return providers
+}
+
+// configResource returns the configuration of the resource, or nil if the
+// plan does not include it.
+func configResource(config *tfjson.Config, rc *tfjson.ResourceChange) *tfjson.ConfigResource {
+	if config == nil || config.RootModule == nil {
+		return nil
+	}
+
+	mod := config.RootModule
+	for _, name := range moduleCallNames(rc.ModuleAddress) {
+		call, ok := mod.ModuleCalls[name]
+		if !ok || call.Module == nil {
+			return nil
+		}
+		mod = call.Module
+	}
+
+	for _, r := range mod.Resources {
+		if r.Mode == rc.Mode && r.Type == rc.Type && r.Name == rc.Name {
+			return r
+		}
+	}
+	return nil
+}
+
+// isConfigured returns whether the flattened attribute is set by one of the
+// expressions. Nested blocks are followed, so that only the attributes set
+// inside a block are considered configured.
+func isConfigured(exprs map[string]*tfjson.Expression, attr string) bool {
+	name, rest, _ := strings.Cut(attr, ".")
+
+	expr, ok := exprs[name]
+	if !ok || expr == nil {
+		return false
+	}
+	if expr.ExpressionData == nil || len(expr.NestedBlocks) == 0 || rest == "" {
+		return true
+	}
+
+	// Attributes inside nested blocks are flattened as "block.INDEX.attr".
+	index, rest, _ := strings.Cut(rest, ".")
+	if index == "#" {
+		return true
+	}
+	i, err := strconv.Atoi(index)
+	if err != nil || i >= len(expr.NestedBlocks) {
+		return false
+	}
+	if rest == "" {
+		return true
+	}
+
+	return isConfigured(expr.NestedBlocks[i], rest)
+}
+
+// moduleCallNames returns the names of the module calls in a module address,
+// without instance keys. For example, `module.a["x"].module.b` becomes
+// ["a", "b"].
+func moduleCallNames(addr string) []string {
+	var names []string
+
+	for addr != "" {
+		addr = strings.TrimPrefix(addr, "module.")
+
+		end := strings.IndexAny(addr, ".[")
+		if end < 0 {
+			names = append(names, addr)
+			break
+		}
+		names = append(names, addr[:end])
+		addr = addr[end:]
+
+		if addr[0] == '[' {
+			addr = addr[closingBracket(addr)+1:]
+		}
+		addr = strings.TrimPrefix(addr, ".")
+	}
+
+	return names
+}
+
+// closingBracket returns the index of the bracket closing the one s starts
+// with. Brackets inside quoted keys are skipped.
+func closingBracket(s string) int {
+	inString := false
+	for i := 1; i < len(s); i++ {
+		switch {
+		case inString && s[i] == '\\':
+			i++
+		case s[i] == '"':
+			inString = !inString
+		case !inString && s[i] == ']':
+			return i
+		}
+	}
+	return len(s) - 1
+}
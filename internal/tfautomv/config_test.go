@@ -0,0 +1,153 @@
+package tfautomv
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestConfiguredAttributes(t *testing.T) {
+	subnet := &tfjson.ConfigResource{
+		Address: "aws_subnet.this",
+		Mode:    tfjson.ManagedResourceMode,
+		Type:    "aws_subnet",
+		Name:    "this",
+		Expressions: map[string]*tfjson.Expression{
+			"cidr_block": {ExpressionData: &tfjson.ExpressionData{References: []string{"var.cidr"}}},
+			"tags":       {ExpressionData: &tfjson.ExpressionData{ConstantValue: map[string]interface{}{"Name": "public"}}},
+			"timeouts": {ExpressionData: &tfjson.ExpressionData{
+				NestedBlocks: []map[string]*tfjson.Expression{
+					{"create": {ExpressionData: &tfjson.ExpressionData{ConstantValue: "10m"}}},
+				},
+			}},
+		},
+	}
+
+	config := &tfjson.Config{
+		RootModule: &tfjson.ConfigModule{
+			ModuleCalls: map[string]*tfjson.ModuleCall{
+				"network": {
+					Module: &tfjson.ConfigModule{
+						Resources: []*tfjson.ConfigResource{subnet},
+					},
+				},
+			},
+		},
+	}
+
+	attrs := []string{
+		"arn",
+		"cidr_block",
+		"id",
+		"tags.Name",
+		"timeouts.#",
+		"timeouts.0.create",
+		"timeouts.0.delete",
+	}
+
+	tt := []struct {
+		name   string
+		config *tfjson.Config
+		rc     *tfjson.ResourceChange
+		want   []string
+	}{
+		{
+			name:   "resource in module instance",
+			config: config,
+			rc: &tfjson.ResourceChange{
+				ModuleAddress: `module.network["a.b"]`,
+				Mode:          tfjson.ManagedResourceMode,
+				Type:          "aws_subnet",
+				Name:          "this",
+			},
+			want: []string{"cidr_block", "tags.Name", "timeouts.#", "timeouts.0.create"},
+		},
+		{
+			name:   "unknown module",
+			config: config,
+			rc: &tfjson.ResourceChange{
+				ModuleAddress: "module.other",
+				Mode:          tfjson.ManagedResourceMode,
+				Type:          "aws_subnet",
+				Name:          "this",
+			},
+			want: nil,
+		},
+		{
+			name:   "no configuration",
+			config: nil,
+			rc: &tfjson.ResourceChange{
+				Mode: tfjson.ManagedResourceMode,
+				Type: "aws_subnet",
+				Name: "this",
+			},
+			want: nil,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			configured := configuredAttributes(tc.config, tc.rc, attrs)
+
+			if tc.want == nil {
+				if configured != nil {
+					t.Errorf("configuredAttributes() = %v, want nil", configured)
+				}
+				return
+			}
+
+			var got []string
+			for attr := range configured {
+				got = append(got, attr)
+			}
+			sort.Strings(got)
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("configuredAttributes() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestModuleCallNames(t *testing.T) {
+	tt := []struct {
+		addr string
+		want []string
+	}{
+		{"", nil},
+		{"module.a", []string{"a"}},
+		{`module.a["x.y"].module.b[0]`, []string{"a", "b"}},
+		{`module.a["]"].module.b`, []string{"a", "b"}},
+	}
+
+	for _, tc := range tt {
+		if got := moduleCallNames(tc.addr); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("moduleCallNames(%q) = %q, want %q", tc.addr, got, tc.want)
+		}
+	}
+}
+
+func TestProvidersWithSeveralConfigs(t *testing.T) {
+	plan := &tfjson.Plan{
+		Config: &tfjson.Config{
+			ProviderConfigs: map[string]*tfjson.ProviderConfig{
+				"aws":                   {Name: "aws", FullName: "registry.terraform.io/hashicorp/aws"},
+				"aws.west":              {Name: "aws", FullName: "registry.terraform.io/hashicorp/aws", Alias: "west"},
+				"module.storage:aws":    {Name: "aws", FullName: "registry.terraform.io/hashicorp/aws", ModuleAddress: "module.storage"},
+				"random":                {Name: "random", FullName: "registry.terraform.io/hashicorp/random"},
+				"module.storage:google": {Name: "google"},
+			},
+		},
+	}
+
+	want := []string{"registry.terraform.io/hashicorp/aws"}
+
+	if got := ProvidersWithSeveralConfigs(plan); !reflect.DeepEqual(got, want) {
+		t.Errorf("ProvidersWithSeveralConfigs() = %q, want %q", got, want)
+	}
+	if got := ProvidersWithSeveralConfigs(&tfjson.Plan{}); got != nil {
+		t.Errorf("ProvidersWithSeveralConfigs() = %q for a plan without configuration, want nil", got)
+	}
+}
@@ -0,0 +1,225 @@
+package tfautomv
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// preferDependencies resolves ambiguities between resources that are only
+// told apart by the resources depending on them. When a resource stays at the
+// same address but its configuration now references another resource, that
+// resource likely replaces the one it depended on in the state. When a
+// resource planned for creation and a resource planned for destruction share
+// such a dependent, and neither of them shares one with any other resource it
+// matches, their other matches are made inconclusive.
+func (a *Analysis) preferDependencies() {
+	linked := make(map[*Resource][]*Resource)
+	shared := make(map[[2]*Resource]string)
+	for _, created := range a.Created() {
+		for _, comp := range a.Comparisons[created] {
+			if !comp.IsMatch() {
+				continue
+			}
+			dependent := sharedDependent(comp.Created, comp.Destroyed)
+			if dependent == "" {
+				continue
+			}
+			linked[comp.Created] = append(linked[comp.Created], comp.Destroyed)
+			linked[comp.Destroyed] = append(linked[comp.Destroyed], comp.Created)
+			shared[[2]*Resource{comp.Created, comp.Destroyed}] = dependent
+		}
+	}
+
+	for _, created := range a.Created() {
+		if len(linked[created]) != 1 {
+			continue
+		}
+		destroyed := linked[created][0]
+		if len(linked[destroyed]) != 1 {
+			continue
+		}
+		dependent := shared[[2]*Resource{created, destroyed}]
+
+		for _, r := range []*Resource{created, destroyed} {
+			for _, comp := range a.Comparisons[r] {
+				isPair := comp.Created == created && comp.Destroyed == destroyed
+				if isPair || !comp.IsMatch() {
+					continue
+				}
+				reason := fmt.Sprintf("%s now depends on %s instead of %s", dependent, created.Address, destroyed.Address)
+				a.setInconclusive(comp.Created, comp.Destroyed, reason)
+			}
+		}
+	}
+}
+
+// sharedDependent returns the address of a resource that depends on
+// destroyed in the state and on created in the configuration, or an empty
+// string if there is none.
+func sharedDependent(created, destroyed *Resource) string {
+	if created.Workdir != destroyed.Workdir {
+		return ""
+	}
+	for _, dependent := range created.Dependents {
+		for _, d := range destroyed.Dependents {
+			if dependent == d {
+				return dependent
+			}
+		}
+	}
+	return ""
+}
+
+// configAddress returns the address of the resource's configuration: its
+// address without the instance keys of the resource or of the modules
+// containing it.
+func (r *Resource) configAddress() string {
+	return withoutKeys(r.ResourceAddress())
+}
+
+// stateDependents indexes resources by the address of the configuration of
+// each resource that depends on them in the state. Addresses are sorted and
+// never include instance keys.
+func stateDependents(state *tfjson.State) map[string][]string {
+	dependents := make(map[string]map[string]bool)
+
+	var walk func(m *tfjson.StateModule)
+	walk = func(m *tfjson.StateModule) {
+		if m == nil {
+			return
+		}
+		for _, r := range m.Resources {
+			for _, dep := range r.DependsOn {
+				addDependent(dependents, withoutKeys(dep), withoutKeys(r.Address))
+			}
+		}
+		for _, child := range m.ChildModules {
+			walk(child)
+		}
+	}
+	if state != nil && state.Values != nil {
+		walk(state.Values.RootModule)
+	}
+
+	return sortedDependents(dependents)
+}
+
+// configDependents indexes resources by the address of the configuration of
+// each resource whose configuration references them. Addresses are sorted
+// and never include instance keys.
+func configDependents(config *tfjson.Config) map[string][]string {
+	dependents := make(map[string]map[string]bool)
+
+	var walk func(m *tfjson.ConfigModule, prefix string)
+	walk = func(m *tfjson.ConfigModule, prefix string) {
+		if m == nil {
+			return
+		}
+		for _, r := range m.Resources {
+			addr := prefix + r.Type + "." + r.Name
+			if r.Mode == tfjson.DataResourceMode {
+				addr = prefix + "data." + r.Type + "." + r.Name
+			}
+
+			refs := append([]string(nil), r.DependsOn...)
+			refs = append(refs, references(r.Expressions)...)
+			for _, expr := range []*tfjson.Expression{r.CountExpression, r.ForEachExpression} {
+				if expr != nil && expr.ExpressionData != nil {
+					refs = append(refs, expr.References...)
+				}
+			}
+
+			for _, ref := range refs {
+				if target, ok := referencedResource(ref); ok {
+					addDependent(dependents, prefix+target, addr)
+				}
+			}
+		}
+		for name, call := range m.ModuleCalls {
+			if call != nil {
+				walk(call.Module, prefix+"module."+name+".")
+			}
+		}
+	}
+	if config != nil {
+		walk(config.RootModule, "")
+	}
+
+	return sortedDependents(dependents)
+}
+
+// references lists the references made by the expressions, including those
+// in nested blocks.
+func references(exprs map[string]*tfjson.Expression) []string {
+	var refs []string
+	for _, expr := range exprs {
+		if expr == nil || expr.ExpressionData == nil {
+			continue
+		}
+		refs = append(refs, expr.References...)
+		for _, block := range expr.NestedBlocks {
+			refs = append(refs, references(block)...)
+		}
+	}
+	return refs
+}
+
+// referencedResource returns the address of the resource a reference made in
+// a module's configuration points to, relative to that module. References to
+// anything other than a resource are ignored.
+func referencedResource(ref string) (string, bool) {
+	parts := strings.Split(withoutKeys(ref), ".")
+
+	switch parts[0] {
+	case "count", "each", "local", "module", "path", "self", "terraform", "var":
+		return "", false
+	case "data":
+		if len(parts) < 3 {
+			return "", false
+		}
+		return strings.Join(parts[:3], "."), true
+	}
+
+	if len(parts) < 2 {
+		return "", false
+	}
+	return parts[0] + "." + parts[1], true
+}
+
+func addDependent(dependents map[string]map[string]bool, target, dependent string) {
+	if target == dependent {
+		return
+	}
+	if dependents[target] == nil {
+		dependents[target] = make(map[string]bool)
+	}
+	dependents[target][dependent] = true
+}
+
+func sortedDependents(dependents map[string]map[string]bool) map[string][]string {
+	sorted := make(map[string][]string, len(dependents))
+	for target, set := range dependents {
+		for dependent := range set {
+			sorted[target] = append(sorted[target], dependent)
+		}
+		sort.Strings(sorted[target])
+	}
+	return sorted
+}
+
+// withoutKeys removes all instance keys from an address, like
+// `module.a["x"].aws_instance.b[0]`, which becomes `module.a.aws_instance.b`.
+func withoutKeys(addr string) string {
+	var b strings.Builder
+	for i := 0; i < len(addr); i++ {
+		if addr[i] == '[' {
+			i += closingBracket(addr[i:])
+			continue
+		}
+		b.WriteByte(addr[i])
+	}
+	return b.String()
+}
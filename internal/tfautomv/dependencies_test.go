@@ -0,0 +1,152 @@
+package tfautomv
+
+import (
+	"reflect"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+
+	"github.com/busser/tfautomv/internal/terraform"
+)
+
+// dependencyPlan returns a plan where two identical random_integer resources
+// are renamed, and each random_pet that used one of them now uses one of the
+// renamed ones, as described by refs.
+func dependencyPlan(refs map[string]string) *tfjson.Plan {
+	plan := &tfjson.Plan{
+		PriorState: &tfjson.State{
+			Values: &tfjson.StateValues{
+				RootModule: &tfjson.StateModule{
+					Resources: []*tfjson.StateResource{
+						{Address: "random_integer.first"},
+						{Address: "random_integer.second"},
+						{Address: "random_pet.first", DependsOn: []string{"random_integer.first"}},
+						{Address: "random_pet.second", DependsOn: []string{"random_integer.second"}},
+					},
+				},
+			},
+		},
+		Config: &tfjson.Config{
+			RootModule: &tfjson.ConfigModule{},
+		},
+	}
+
+	for _, name := range []string{"alpha", "beta"} {
+		plan.ResourceChanges = append(plan.ResourceChanges, &tfjson.ResourceChange{
+			Address: "random_integer." + name,
+			Mode:    tfjson.ManagedResourceMode,
+			Type:    "random_integer",
+			Name:    name,
+			Change: &tfjson.Change{
+				Actions:      []tfjson.Action{tfjson.ActionCreate},
+				After:        map[string]interface{}{"min": 1, "max": 5},
+				AfterUnknown: map[string]interface{}{"result": true},
+			},
+		})
+	}
+	for _, name := range []string{"first", "second"} {
+		plan.ResourceChanges = append(plan.ResourceChanges, &tfjson.ResourceChange{
+			Address: "random_integer." + name,
+			Mode:    tfjson.ManagedResourceMode,
+			Type:    "random_integer",
+			Name:    name,
+			Change: &tfjson.Change{
+				Actions: []tfjson.Action{tfjson.ActionDelete},
+				Before:  map[string]interface{}{"min": 1, "max": 5, "result": 3},
+			},
+		})
+	}
+	for _, name := range []string{"first", "second"} {
+		plan.Config.RootModule.Resources = append(plan.Config.RootModule.Resources, &tfjson.ConfigResource{
+			Address: "random_pet." + name,
+			Mode:    tfjson.ManagedResourceMode,
+			Type:    "random_pet",
+			Name:    name,
+			Expressions: map[string]*tfjson.Expression{
+				"length": {ExpressionData: &tfjson.ExpressionData{
+					References: []string{refs[name] + ".result", refs[name]},
+				}},
+			},
+		})
+	}
+
+	return plan
+}
+
+func TestAnalysisFromPlanDependencies(t *testing.T) {
+	tt := []struct {
+		name string
+		refs map[string]string
+		want []terraform.Move
+	}{
+		{
+			name: "each dependent uses a different resource",
+			refs: map[string]string{
+				"first":  "random_integer.alpha",
+				"second": "random_integer.beta",
+			},
+			want: []terraform.Move{
+				{From: "random_integer.first", To: "random_integer.alpha"},
+				{From: "random_integer.second", To: "random_integer.beta"},
+			},
+		},
+		{
+			name: "each dependent uses the other resource",
+			refs: map[string]string{
+				"first":  "random_integer.beta",
+				"second": "random_integer.alpha",
+			},
+			want: []terraform.Move{
+				{From: "random_integer.first", To: "random_integer.beta"},
+				{From: "random_integer.second", To: "random_integer.alpha"},
+			},
+		},
+		{
+			name: "both dependents use the same resource",
+			refs: map[string]string{
+				"first":  "random_integer.alpha",
+				"second": "random_integer.alpha",
+			},
+			want: nil,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			analysis, err := AnalysisFromPlan(dependencyPlan(tc.refs), nil, AnalysisOptions{})
+			if err != nil {
+				t.Fatalf("AnalysisFromPlan(): unexpected error: %v", err)
+			}
+
+			got := MovesFromAnalysis(analysis, MovesOptions{})
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("MovesFromAnalysis() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReferencedResource(t *testing.T) {
+	tt := []struct {
+		ref    string
+		want   string
+		wantOK bool
+	}{
+		{ref: "random_integer.alpha", want: "random_integer.alpha", wantOK: true},
+		{ref: "random_integer.alpha.result", want: "random_integer.alpha", wantOK: true},
+		{ref: `random_integer.alpha["x"].result`, want: "random_integer.alpha", wantOK: true},
+		{ref: "data.aws_ami.ubuntu.id", want: "data.aws_ami.ubuntu", wantOK: true},
+		{ref: "var.length"},
+		{ref: "local.tags"},
+		{ref: "module.network.subnet_id"},
+		{ref: "each.value"},
+		{ref: "count.index"},
+	}
+
+	for _, tc := range tt {
+		got, ok := referencedResource(tc.ref)
+		if got != tc.want || ok != tc.wantOK {
+			t.Errorf("referencedResource(%q) = %q, %t, want %q, %t", tc.ref, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package tfautomv
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// A TypeEquivalence declares that resources of one type can be moved to
+// another type. Terraform supports such moves since version 1.8, for the
+// types whose provider implements them.
+type TypeEquivalence struct {
+	// Type of resources planned for destruction.
+	From string
+
+	// Type of resources planned for creation.
+	To string
+
+	// Attributes maps attributes of From to the attributes of To they are
+	// equivalent to. Attributes not listed have the same name in both types.
+	// Attributes nested in a renamed attribute are renamed as well.
+	Attributes map[string]string
+}
+
+// builtinTypeEquivalences lists the resource types known to support moves
+// between them, with the first Terraform version supporting each move.
+var builtinTypeEquivalences = []struct {
+	minVersion *version.Version
+	eq         TypeEquivalence
+}{
+	// Terraform's built-in terraform_data resource replaces null_resource.
+	// It accepts moves from null_resource since Terraform 1.9.
+	{
+		minVersion: version.Must(version.NewVersion("1.9")),
+		eq:         TypeEquivalence{From: "null_resource", To: "terraform_data", Attributes: map[string]string{"triggers": "triggers_replace"}},
+	},
+}
+
+// BuiltinTypeEquivalences returns the built-in type equivalences that
+// Terraform version tfVer can move resources between. If tfVer is nil, all of
+// them are returned.
+func BuiltinTypeEquivalences(tfVer *version.Version) []TypeEquivalence {
+	var equivalences []TypeEquivalence
+	for _, b := range builtinTypeEquivalences {
+		if tfVer == nil || !tfVer.LessThan(b.minVersion) {
+			equivalences = append(equivalences, b.eq)
+		}
+	}
+	return equivalences
+}
+
+// ParseTypeEquivalence parses a type equivalence from a string of the form
+// "FROM:TO" or "FROM:TO:ATTR=ATTR,ATTR=ATTR", where each pair of attributes
+// maps an attribute of FROM to one of TO.
+func ParseTypeEquivalence(raw string) (TypeEquivalence, error) {
+	parts := strings.SplitN(raw, ":", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return TypeEquivalence{}, fmt.Errorf("expected \"FROM:TO\" or \"FROM:TO:ATTR=ATTR,...\"")
+	}
+
+	eq := TypeEquivalence{
+		From: parts[0],
+		To:   parts[1],
+	}
+	if eq.From == eq.To {
+		return TypeEquivalence{}, fmt.Errorf("types must be different")
+	}
+
+	if len(parts) == 3 {
+		eq.Attributes = make(map[string]string)
+		for _, pair := range strings.Split(parts[2], ",") {
+			from, to, ok := strings.Cut(pair, "=")
+			if !ok || from == "" || to == "" {
+				return TypeEquivalence{}, fmt.Errorf("invalid attribute mapping %q: expected \"ATTR=ATTR\"", pair)
+			}
+			eq.Attributes[from] = to
+		}
+	}
+
+	return eq, nil
+}
+
+// renames returns the name each renamed attribute of To has in From.
+func (eq TypeEquivalence) renames() map[string]string {
+	if len(eq.Attributes) == 0 {
+		return nil
+	}
+	renames := make(map[string]string, len(eq.Attributes))
+	for from, to := range eq.Attributes {
+		renames[to] = from
+	}
+	return renames
+}
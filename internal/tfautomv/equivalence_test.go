@@ -0,0 +1,192 @@
+package tfautomv
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	tfjson "github.com/hashicorp/terraform-json"
+
+	"github.com/busser/tfautomv/internal/terraform"
+)
+
+func TestParseTypeEquivalence(t *testing.T) {
+	tt := []struct {
+		raw     string
+		want    TypeEquivalence
+		wantErr bool
+	}{
+		{
+			raw:  "aws_alb:aws_lb",
+			want: TypeEquivalence{From: "aws_alb", To: "aws_lb"},
+		},
+		{
+			raw: "null_resource:terraform_data:triggers=triggers_replace,id=id",
+			want: TypeEquivalence{
+				From: "null_resource",
+				To:   "terraform_data",
+				Attributes: map[string]string{
+					"triggers": "triggers_replace",
+					"id":       "id",
+				},
+			},
+		},
+		{
+			raw:     "aws_alb",
+			wantErr: true,
+		},
+		{
+			raw:     ":aws_lb",
+			wantErr: true,
+		},
+		{
+			raw:     "aws_lb:aws_lb",
+			wantErr: true,
+		},
+		{
+			raw:     "null_resource:terraform_data:triggers",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.raw, func(t *testing.T) {
+			got, err := ParseTypeEquivalence(tc.raw)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("ParseTypeEquivalence() should have returned an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTypeEquivalence() returned error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ParseTypeEquivalence() = %#v, want %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAnalysisFromPlanTypeEquivalences(t *testing.T) {
+	plan := &tfjson.Plan{
+		ResourceChanges: []*tfjson.ResourceChange{
+			{
+				Address:      "terraform_data.this",
+				Type:         "terraform_data",
+				ProviderName: "terraform.io/builtin/terraform",
+				Change: &tfjson.Change{
+					Actions: []tfjson.Action{tfjson.ActionCreate},
+					After: map[string]interface{}{
+						"triggers_replace": map[string]interface{}{"version": "1"},
+					},
+				},
+			},
+			{
+				Address:      "null_resource.this",
+				Type:         "null_resource",
+				ProviderName: "registry.terraform.io/hashicorp/null",
+				Change: &tfjson.Change{
+					Actions: []tfjson.Action{tfjson.ActionDelete},
+					Before: map[string]interface{}{
+						"id":       "123",
+						"triggers": map[string]interface{}{"version": "1"},
+					},
+				},
+			},
+		},
+	}
+
+	tt := []struct {
+		name         string
+		equivalences []TypeEquivalence
+		wantMatching []string
+		wantMoves    []terraform.Move
+	}{
+		{
+			name:         "without equivalences",
+			equivalences: nil,
+			wantMatching: nil,
+			wantMoves:    nil,
+		},
+		{
+			name:         "with built-in equivalences of Terraform 1.8",
+			equivalences: BuiltinTypeEquivalences(version.Must(version.NewVersion("1.8"))),
+			wantMatching: nil,
+			wantMoves:    nil,
+		},
+		{
+			name:         "with built-in equivalences of Terraform 1.9",
+			equivalences: BuiltinTypeEquivalences(version.Must(version.NewVersion("1.9"))),
+			wantMatching: []string{"triggers_replace.version"},
+			wantMoves: []terraform.Move{
+				{From: "null_resource.this", To: "terraform_data.this"},
+			},
+		},
+		{
+			name: "with a duplicate equivalence",
+			equivalences: append(
+				BuiltinTypeEquivalences(version.Must(version.NewVersion("1.9"))),
+				TypeEquivalence{From: "null_resource", To: "terraform_data", Attributes: map[string]string{"triggers": "triggers_replace"}},
+			),
+			wantMatching: []string{"triggers_replace.version"},
+			wantMoves: []terraform.Move{
+				{From: "null_resource.this", To: "terraform_data.this"},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			analysis, err := AnalysisFromPlan(plan, nil, AnalysisOptions{TypeEquivalences: tc.equivalences})
+			if err != nil {
+				t.Fatalf("AnalysisFromPlan(): unexpected error: %v", err)
+			}
+
+			comps := analysis.Comparisons[analysis.CreatedByType["terraform_data"][0]]
+			if tc.wantMatching == nil {
+				if len(comps) != 0 {
+					t.Errorf("got %d comparisons, want none", len(comps))
+				}
+			} else {
+				if len(comps) != 1 {
+					t.Fatalf("got %d comparisons, want 1", len(comps))
+				}
+				got := append([]string(nil), comps[0].MatchingAttributes...)
+				sort.Strings(got)
+				if !reflect.DeepEqual(got, tc.wantMatching) {
+					t.Errorf("MatchingAttributes = %q, want %q", got, tc.wantMatching)
+				}
+				if attr := comps[0].DestroyedAttribute("triggers_replace.version"); attr != "triggers.version" {
+					t.Errorf("DestroyedAttribute() = %q, want %q", attr, "triggers.version")
+				}
+			}
+
+			moves := MovesFromAnalysis(analysis, MovesOptions{})
+			if !reflect.DeepEqual(moves, tc.wantMoves) {
+				t.Errorf("MovesFromAnalysis() = %v, want %v", moves, tc.wantMoves)
+			}
+		})
+	}
+}
+
+func TestAnalysisOptionsTypeEquivalences(t *testing.T) {
+	opts := AnalysisOptions{
+		TypeEquivalences: append(
+			BuiltinTypeEquivalences(nil),
+			TypeEquivalence{From: "aws_alb", To: "aws_lb"},
+			TypeEquivalence{From: "null_resource", To: "terraform_data"},
+			TypeEquivalence{From: "aws_alb", To: "aws_lb"},
+		),
+	}
+
+	want := []TypeEquivalence{
+		{From: "null_resource", To: "terraform_data"},
+		{From: "aws_alb", To: "aws_lb"},
+	}
+
+	if got := opts.typeEquivalences(); !reflect.DeepEqual(got, want) {
+		t.Errorf("typeEquivalences() = %#v, want %#v", got, want)
+	}
+}
@@ -3,8 +3,42 @@ package ignore
 type baseRule struct {
 	resourceType string
 	attribute    string
+
+	// Compiled selectors, for resource types and attributes that are not
+	// literal strings. Nil otherwise.
+	resourceTypeMatcher matcher
+	attributeMatcher    matcher
+}
+
+func newBaseRule(resourceType, attribute string) (baseRule, error) {
+	r := baseRule{
+		resourceType: resourceType,
+		attribute:    attribute,
+	}
+
+	var err error
+
+	r.resourceTypeMatcher, err = compileSelector(resourceType)
+	if err != nil {
+		return baseRule{}, err
+	}
+
+	r.attributeMatcher, err = compileSelector(attribute)
+	if err != nil {
+		return baseRule{}, err
+	}
+
+	return r, nil
 }
 
 func (r baseRule) AppliesTo(resourceType, attribute string) bool {
-	return resourceType == r.resourceType && attribute == r.attribute
+	return selects(r.resourceType, r.resourceTypeMatcher, resourceType) &&
+		selects(r.attribute, r.attributeMatcher, attribute)
+}
+
+func selects(selector string, m matcher, s string) bool {
+	if m == nil {
+		return s == selector
+	}
+	return m.MatchString(s)
 }
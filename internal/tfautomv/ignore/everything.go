@@ -3,7 +3,6 @@ package ignore
 import (
 	"errors"
 	"fmt"
-	"strings"
 )
 
 type everythingRule struct {
@@ -11,16 +10,21 @@ type everythingRule struct {
 }
 
 func parseEverythingRule(s string) (*everythingRule, error) {
-	parts := strings.Split(s, ":")
+	parts, err := splitFields(s, -1)
+	if err != nil {
+		return nil, err
+	}
 	if len(parts) != 2 {
 		return nil, errors.New("syntax error")
 	}
 
+	base, err := newBaseRule(parts[0], parts[1])
+	if err != nil {
+		return nil, err
+	}
+
 	r := everythingRule{
-		baseRule: baseRule{
-			resourceType: parts[0],
-			attribute:    parts[1],
-		},
+		baseRule: base,
 	}
 
 	return &r, nil
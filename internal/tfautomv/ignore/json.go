@@ -0,0 +1,60 @@
+package ignore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+type jsonRule struct {
+	baseRule
+}
+
+func parseJSONRule(s string) (*jsonRule, error) {
+	parts, err := splitFields(s, -1)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) != 2 {
+		return nil, errors.New("syntax error")
+	}
+
+	base, err := newBaseRule(parts[0], parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	r := jsonRule{
+		baseRule: base,
+	}
+
+	return &r, nil
+}
+
+func (r jsonRule) String() string {
+	return fmt.Sprintf("%s:%s:%s", RuleTypeJSON, r.resourceType, r.attribute)
+}
+
+// Equates parses both values as JSON documents and compares the results. If
+// either value is not a string containing valid JSON, the values do not match.
+func (r *jsonRule) Equates(a, b interface{}) bool {
+	aStr, ok := a.(string)
+	if !ok {
+		return false
+	}
+	bStr, ok := b.(string)
+	if !ok {
+		return false
+	}
+
+	var aDoc, bDoc interface{}
+	if err := json.Unmarshal([]byte(aStr), &aDoc); err != nil {
+		return false
+	}
+	if err := json.Unmarshal([]byte(bStr), &bDoc); err != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(aDoc, bDoc)
+}
@@ -0,0 +1,114 @@
+package ignore
+
+import "testing"
+
+func TestJSONRuleAppliesTo(t *testing.T) {
+	rule := jsonRule{
+		baseRule{
+			resourceType: "my_resource",
+			attribute:    "my_attr",
+		},
+	}
+
+	tt := []struct {
+		resourceType string
+		attribute    string
+		want         bool
+	}{
+		{
+			resourceType: "my_resource",
+			attribute:    "my_attr",
+			want:         true,
+		},
+		{
+			resourceType: "not_my_resource",
+			attribute:    "my_attr",
+			want:         false,
+		},
+		{
+			resourceType: "my_resource",
+			attribute:    "not_my_attr",
+			want:         false,
+		},
+		{
+			resourceType: "not_my_resource",
+			attribute:    "not_my_attr",
+			want:         false,
+		},
+	}
+
+	for _, tc := range tt {
+		actual := rule.AppliesTo(tc.resourceType, tc.attribute)
+		if actual != tc.want {
+			t.Errorf("AppliesTo(%q, %q) = %t, want %t", tc.resourceType, tc.attribute, actual, tc.want)
+		}
+	}
+}
+
+func TestJSONRuleEquates(t *testing.T) {
+	rule := jsonRule{
+		baseRule{
+			resourceType: "my_resource",
+			attribute:    "my_attr",
+		},
+	}
+
+	tt := []struct {
+		valueA interface{}
+		valueB interface{}
+		want   bool
+	}{
+		{
+			valueA: `{"foo":"bar"}`,
+			valueB: `{"foo":"bar"}`,
+			want:   true,
+		},
+		{
+			valueA: `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject"}]}`,
+			valueB: "{\n  \"Statement\": [\n    {\n      \"Action\": \"s3:GetObject\",\n      \"Effect\": \"Allow\"\n    }\n  ],\n  \"Version\": \"2012-10-17\"\n}",
+			want:   true,
+		},
+		{
+			valueA: `{"name":"foo bar"}`,
+			valueB: `{"name":"foobar"}`,
+			want:   false,
+		},
+		{
+			valueA: `{"list":[1,2]}`,
+			valueB: `{"list":[2,1]}`,
+			want:   false,
+		},
+		{
+			valueA: `{"number":1}`,
+			valueB: `{"number":1.0}`,
+			want:   true,
+		},
+		{
+			valueA: `{"foo":"bar"}`,
+			valueB: `{"foo":"bar"`,
+			want:   false,
+		},
+		{
+			valueA: `not json`,
+			valueB: `not  json`,
+			want:   false,
+		},
+		{
+			valueA: 123,
+			valueB: 123,
+			want:   false,
+		},
+		{
+			valueA: `123`,
+			valueB: 123,
+			want:   false,
+		},
+	}
+
+	for _, tc := range tt {
+		actual := rule.Equates(tc.valueA, tc.valueB)
+		if actual != tc.want {
+			t.Errorf("Equates(%#v, %#v) = %t, want %t", tc.valueA, tc.valueB, actual, tc.want)
+		}
+	}
+}
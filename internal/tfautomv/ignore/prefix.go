@@ -13,17 +13,22 @@ type prefixRule struct {
 }
 
 func parsePrefixRule(s string) (*prefixRule, error) {
-	parts := strings.SplitN(s, ":", 3)
+	parts, err := splitFields(s, 3)
+	if err != nil {
+		return nil, err
+	}
 	if len(parts) != 3 {
 		return nil, errors.New("syntax error")
 	}
 
+	base, err := newBaseRule(parts[0], parts[1])
+	if err != nil {
+		return nil, err
+	}
+
 	r := prefixRule{
-		baseRule: baseRule{
-			resourceType: parts[0],
-			attribute:    parts[1],
-		},
-		prefix: parts[2],
+		baseRule: base,
+		prefix:   parts[2],
 	}
 
 	return &r, nil
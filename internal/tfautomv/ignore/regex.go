@@ -0,0 +1,70 @@
+package ignore
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+)
+
+type regexRule struct {
+	baseRule
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+func parseRegexRule(s string) (*regexRule, error) {
+	parts, err := splitFields(s, 4)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) != 4 {
+		return nil, errors.New("syntax error")
+	}
+
+	base, err := newBaseRule(parts[0], parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if !isRegexpSelector(parts[2]) {
+		return nil, fmt.Errorf("regular expression %q must be between slashes", parts[2])
+	}
+	pattern, err := regexp.Compile(parts[2][1 : len(parts[2])-1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %s: %w", parts[2], err)
+	}
+
+	r := regexRule{
+		baseRule:    base,
+		pattern:     pattern,
+		replacement: parts[3],
+	}
+
+	return &r, nil
+}
+
+func (r regexRule) String() string {
+	return fmt.Sprintf("%s:%s:%s:/%s/:%s", RuleTypeRegex, r.resourceType, r.attribute, r.pattern, r.replacement)
+}
+
+func (r *regexRule) Equates(a, b interface{}) bool {
+	aVal := reflect.ValueOf(a)
+	bVal := reflect.ValueOf(b)
+
+	if aVal.Kind() != bVal.Kind() {
+		return false
+	}
+	kind := aVal.Kind()
+
+	var aStr, bStr string
+	if kind == reflect.String {
+		aStr = aVal.String()
+		bStr = bVal.String()
+	} else {
+		aStr = fmt.Sprint(a)
+		bStr = fmt.Sprint(b)
+	}
+
+	return r.pattern.ReplaceAllString(aStr, r.replacement) == r.pattern.ReplaceAllString(bStr, r.replacement)
+}
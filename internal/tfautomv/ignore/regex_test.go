@@ -0,0 +1,169 @@
+package ignore
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestRegexRuleAppliesTo(t *testing.T) {
+	rule := regexRule{
+		baseRule{
+			resourceType: "my_resource",
+			attribute:    "my_attr",
+		},
+		regexp.MustCompile("does-not-matter"),
+		"",
+	}
+
+	tt := []struct {
+		resourceType string
+		attribute    string
+		want         bool
+	}{
+		{
+			resourceType: "my_resource",
+			attribute:    "my_attr",
+			want:         true,
+		},
+		{
+			resourceType: "not_my_resource",
+			attribute:    "my_attr",
+			want:         false,
+		},
+		{
+			resourceType: "my_resource",
+			attribute:    "not_my_attr",
+			want:         false,
+		},
+		{
+			resourceType: "not_my_resource",
+			attribute:    "not_my_attr",
+			want:         false,
+		},
+	}
+
+	for _, tc := range tt {
+		actual := rule.AppliesTo(tc.resourceType, tc.attribute)
+		if actual != tc.want {
+			t.Errorf("AppliesTo(%q, %q) = %t, want %t", tc.resourceType, tc.attribute, actual, tc.want)
+		}
+	}
+}
+
+func TestRegexRuleEquates(t *testing.T) {
+	tt := []struct {
+		valueA      interface{}
+		valueB      interface{}
+		pattern     string
+		replacement string
+		want        bool
+	}{
+		{
+			valueA:  "foo",
+			valueB:  "foo",
+			pattern: "any",
+			want:    true,
+		},
+		{
+			valueA:  "my-bucket-eu-west-1",
+			valueB:  "my-bucket-us-east-1",
+			pattern: `-[a-z]{2}-[a-z]+-\d$`,
+			want:    true,
+		},
+		{
+			valueA:  "my-bucket-eu-west-1",
+			valueB:  "your-bucket-us-east-1",
+			pattern: `-[a-z]{2}-[a-z]+-\d$`,
+			want:    false,
+		},
+		{
+			valueA:      "arn:aws:iam::123456789012:role/admin",
+			valueB:      "arn:aws:iam::210987654321:role/admin",
+			pattern:     `::\d{12}:`,
+			replacement: "::ACCOUNT:",
+			want:        true,
+		},
+		{
+			valueA:      "app-3f9a1c-web",
+			valueB:      "app-77b2e0-web",
+			pattern:     `^(\w+)-[0-9a-f]{6}-(\w+)$`,
+			replacement: "$1-$2",
+			want:        true,
+		},
+		{
+			valueA:      "app-3f9a1c-web",
+			valueB:      "app-77b2e0-api",
+			pattern:     `^(\w+)-[0-9a-f]{6}-(\w+)$`,
+			replacement: "$1-$2",
+			want:        false,
+		},
+		{
+			valueA:  123,
+			valueB:  124,
+			pattern: `\d$`,
+			want:    true,
+		},
+		{
+			valueA:  123,
+			valueB:  "123",
+			pattern: "any",
+			want:    false,
+		},
+		{
+			valueA:  false,
+			valueB:  "false",
+			pattern: "any",
+			want:    false,
+		},
+	}
+
+	for _, tc := range tt {
+		rule := regexRule{
+			baseRule{
+				resourceType: "my_resource",
+				attribute:    "my_attr",
+			},
+			regexp.MustCompile(tc.pattern),
+			tc.replacement,
+		}
+
+		actual := rule.Equates(tc.valueA, tc.valueB)
+		if actual != tc.want {
+			t.Errorf("Equates(%q, %q) with pattern %q and replacement %q = %t, want %t", tc.valueA, tc.valueB, tc.pattern, tc.replacement, actual, tc.want)
+		}
+	}
+}
+
+func TestParseRegexRule(t *testing.T) {
+	tt := []struct {
+		s       string
+		wantErr bool
+	}{
+		{s: "my_resource:my_attr:/foo/:bar"},
+		{s: "my_resource:my_attr:/foo/:"},
+		{s: "my_resource:my_attr:/(a):(b)/:$1-$2"},
+		{s: "my_resource:my_attr:/foo/:with:colons"},
+		{s: "my_resource:my_attr:/foo/", wantErr: true},
+		{s: "my_resource:my_attr:foo:bar", wantErr: true},
+		{s: "my_resource:my_attr:/(unclosed/:bar", wantErr: true},
+		{s: "my_resource:my_attr", wantErr: true},
+	}
+
+	for _, tc := range tt {
+		actual, err := parseRegexRule(tc.s)
+
+		if err != nil && !tc.wantErr {
+			t.Errorf("parseRegexRule(%q): unexpected error: %v", tc.s, err)
+		}
+		if err == nil && tc.wantErr {
+			t.Errorf("parseRegexRule(%q): expected error, got none", tc.s)
+		}
+		if tc.wantErr {
+			continue
+		}
+
+		if s, want := actual.String(), "regex:"+tc.s; s != want {
+			t.Errorf("String() = %q, want %q", s, want)
+		}
+	}
+}
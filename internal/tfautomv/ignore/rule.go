@@ -14,12 +14,24 @@ const (
 	// values.
 	RuleTypeEverything RuleType = "everything"
 
+	// RuleTypeJSON parses attributes' values as JSON documents and ignores
+	// differences in formatting and key order.
+	RuleTypeJSON RuleType = "json"
+
 	// RuleTypePrefix ignores a given prefix when comparing attribute values.
 	RuleTypePrefix RuleType = "prefix"
 
+	// RuleTypeRegex replaces matches of a regular expression in both
+	// attributes' values before comparing them.
+	RuleTypeRegex RuleType = "regex"
+
 	// RuleTypeWhitespace ignores differences in whitespace between two
 	// attributes' values. Whitespace is as defined by unicode.IsSpace.
 	RuleTypeWhitespace RuleType = "whitespace"
+
+	// RuleTypeYAML parses attributes' values as YAML documents and ignores
+	// differences in formatting and key order.
+	RuleTypeYAML RuleType = "yaml"
 )
 
 // A Rule allows tfautomv to equate certains attribute values that would
@@ -49,10 +61,16 @@ func ParseRule(s string) (Rule, error) {
 	switch ruleType {
 	case RuleTypeEverything:
 		return parseEverythingRule(parts[1])
+	case RuleTypeJSON:
+		return parseJSONRule(parts[1])
 	case RuleTypePrefix:
 		return parsePrefixRule(parts[1])
+	case RuleTypeRegex:
+		return parseRegexRule(parts[1])
 	case RuleTypeWhitespace:
 		return parseWhitespaceRule(parts[1])
+	case RuleTypeYAML:
+		return parseYAMLRule(parts[1])
 	default:
 		return nil, fmt.Errorf("unknown rule type %q", ruleType)
 	}
@@ -49,6 +49,44 @@ func TestParseRule(t *testing.T) {
 			wantErr: true,
 		},
 
+		// JSON rule
+		{
+			s: "json:my_resource:my_attr",
+			want: &jsonRule{
+				baseRule{
+					resourceType: "my_resource",
+					attribute:    "my_attr",
+				},
+			},
+		},
+		{
+			s:       "json:my_resource",
+			wantErr: true,
+		},
+		{
+			s:       "json:my_resource:my_attr:extra",
+			wantErr: true,
+		},
+
+		// YAML rule
+		{
+			s: "yaml:my_resource:my_attr",
+			want: &yamlRule{
+				baseRule{
+					resourceType: "my_resource",
+					attribute:    "my_attr",
+				},
+			},
+		},
+		{
+			s:       "yaml:my_resource",
+			wantErr: true,
+		},
+		{
+			s:       "yaml:my_resource:my_attr:extra",
+			wantErr: true,
+		},
+
 		// Non-existent rule
 		{
 			s:       "doesnotexist:foo:bar",
@@ -84,3 +122,76 @@ func TestParseRule(t *testing.T) {
 		})
 	}
 }
+
+func TestParseRuleWithPatterns(t *testing.T) {
+	tt := []struct {
+		s            string
+		resourceType string
+		attribute    string
+		wantApplies  bool
+		wantErr      bool
+	}{
+		{
+			s:            "everything:aws_*:tags_all.*",
+			resourceType: "aws_instance",
+			attribute:    "tags_all.Name",
+			wantApplies:  true,
+		},
+		{
+			s:            "everything:aws_*:tags_all.*",
+			resourceType: "google_compute_instance",
+			attribute:    "tags_all.Name",
+			wantApplies:  false,
+		},
+		{
+			s:            "whitespace:/^aws_(lb|alb)$/:/^listener\\.\\d+\\.policy$/",
+			resourceType: "aws_alb",
+			attribute:    "listener.3.policy",
+			wantApplies:  true,
+		},
+		{
+			s:            "prefix:kubernetes_*:metadata.0.annotations.*:example.com/",
+			resourceType: "kubernetes_deployment",
+			attribute:    "metadata.0.annotations.owner",
+			wantApplies:  true,
+		},
+		{
+			s:            "prefix:/(?:google|aws)_bucket/:name:b/",
+			resourceType: "google_bucket",
+			attribute:    "name",
+			wantApplies:  true,
+		},
+		{
+			s:       "everything:/(unclosed/:name",
+			wantErr: true,
+		},
+		{
+			s:       "everything:/unterminated:name",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.s, func(t *testing.T) {
+			actual, err := ParseRule(tc.s)
+
+			if err != nil && !tc.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if err == nil && tc.wantErr {
+				t.Errorf("expected error, got none")
+			}
+			if tc.wantErr {
+				return
+			}
+
+			if applies := actual.AppliesTo(tc.resourceType, tc.attribute); applies != tc.wantApplies {
+				t.Errorf("AppliesTo(%q, %q) = %t, want %t", tc.resourceType, tc.attribute, applies, tc.wantApplies)
+			}
+
+			if s := actual.String(); s != tc.s {
+				t.Errorf("String() = %q, want %q", s, tc.s)
+			}
+		})
+	}
+}
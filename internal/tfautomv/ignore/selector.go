@@ -0,0 +1,117 @@
+package ignore
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Rules select the resource types and attributes they apply to with
+// selectors. A selector is one of:
+//
+//   - a literal string, like "aws_instance";
+//   - a glob, where "*" matches any sequence of characters and "?" matches any
+//     single character, like "aws_*";
+//   - a regular expression between slashes, like "/^aws_(lb|alb)$/".
+//
+// Literal strings are the most common selectors and are compared directly.
+// Other selectors are compiled once, when the rule is parsed.
+
+// A matcher reports whether a string matches a selector that is not a literal
+// string.
+type matcher interface {
+	MatchString(s string) bool
+}
+
+// compileSelector returns a matcher for the given selector, or nil if the
+// selector is a literal string.
+func compileSelector(selector string) (matcher, error) {
+	if isRegexpSelector(selector) {
+		re, err := regexp.Compile(selector[1 : len(selector)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %s: %w", selector, err)
+		}
+		return re, nil
+	}
+
+	if strings.ContainsAny(selector, "*?") {
+		return globToRegexp(selector), nil
+	}
+
+	return nil, nil
+}
+
+func isRegexpSelector(selector string) bool {
+	return len(selector) >= 2 && strings.HasPrefix(selector, "/") && strings.HasSuffix(selector, "/")
+}
+
+// globToRegexp compiles a glob into an equivalent regular expression. Go's
+// regular expressions run in linear time, so matching stays fast no matter
+// how many wildcards the glob contains.
+func globToRegexp(glob string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, ch := range glob {
+		switch ch {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	b.WriteString("$")
+
+	return regexp.MustCompile(b.String())
+}
+
+// splitFields splits a rule into at most n colon-separated fields, like
+// strings.SplitN. If n is negative, there is no limit. Colons inside a regular
+// expression between slashes do not split fields.
+func splitFields(s string, n int) ([]string, error) {
+	var fields []string
+
+	for n < 0 || len(fields) < n-1 {
+		end := fieldEnd(s)
+		if end < 0 {
+			return nil, errors.New("unterminated regular expression")
+		}
+		if end == len(s) {
+			break
+		}
+		fields = append(fields, s[:end])
+		s = s[end+1:]
+	}
+
+	return append(fields, s), nil
+}
+
+// fieldEnd returns the index of the colon ending the first field of s, or
+// len(s) if the field is the last one. It returns -1 if the field starts a
+// regular expression that is never closed.
+func fieldEnd(s string) int {
+	if !strings.HasPrefix(s, "/") {
+		if i := strings.IndexByte(s, ':'); i >= 0 {
+			return i
+		}
+		return len(s)
+	}
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '/':
+			if i+1 == len(s) {
+				return len(s)
+			}
+			if s[i+1] == ':' {
+				return i + 1
+			}
+		}
+	}
+
+	return -1
+}
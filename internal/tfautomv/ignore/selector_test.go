@@ -0,0 +1,124 @@
+package ignore
+
+import (
+	"testing"
+
+	"github.com/busser/tfautomv/internal/slices"
+)
+
+func TestSelects(t *testing.T) {
+	tt := []struct {
+		selector string
+		s        string
+		want     bool
+	}{
+		// Literal strings
+		{"aws_instance", "aws_instance", true},
+		{"aws_instance", "aws_instance_state", false},
+		{"tags.Name", "tags.Name", true},
+		{"tags.Name", "tagsxName", false},
+
+		// Globs
+		{"aws_*", "aws_instance", true},
+		{"aws_*", "aws_", true},
+		{"aws_*", "google_compute_instance", false},
+		{"tags_all.*", "tags_all.Name", true},
+		{"tags_all.*", "tags_all", false},
+		{"metadata.0.annotations.*", "metadata.0.annotations.example.com/owner", true},
+		{"metadata.?.annotations.*", "metadata.1.annotations.foo", true},
+		{"metadata.?.annotations.*", "metadata.10.annotations.foo", false},
+		{"*.name", "foo.name", true},
+		{"*.name", "foo.name.bar", false},
+
+		// Regular expressions
+		{"/^aws_(lb|alb)$/", "aws_lb", true},
+		{"/^aws_(lb|alb)$/", "aws_alb", true},
+		{"/^aws_(lb|alb)$/", "aws_elb", false},
+		{"/name/", "tags.name.foo", true},
+		{"/^ingress\\.\\d+\\.cidr_blocks\\.\\d+$/", "ingress.0.cidr_blocks.12", true},
+		{"/^ingress\\.\\d+\\.cidr_blocks\\.\\d+$/", "ingress.0.cidr_blocks.#", false},
+	}
+
+	for _, tc := range tt {
+		m, err := compileSelector(tc.selector)
+		if err != nil {
+			t.Errorf("compileSelector(%q): unexpected error: %v", tc.selector, err)
+			continue
+		}
+
+		actual := selects(tc.selector, m, tc.s)
+		if actual != tc.want {
+			t.Errorf("selector %q matches %q = %t, want %t", tc.selector, tc.s, actual, tc.want)
+		}
+	}
+}
+
+func TestCompileSelector(t *testing.T) {
+	tt := []struct {
+		selector    string
+		wantLiteral bool
+		wantErr     bool
+	}{
+		{selector: "aws_instance", wantLiteral: true},
+		{selector: "tags.Name", wantLiteral: true},
+		{selector: "/", wantLiteral: true},
+		{selector: "aws_*"},
+		{selector: "/^aws_/"},
+		{selector: "/(unclosed/", wantErr: true},
+	}
+
+	for _, tc := range tt {
+		m, err := compileSelector(tc.selector)
+
+		if err != nil && !tc.wantErr {
+			t.Errorf("compileSelector(%q): unexpected error: %v", tc.selector, err)
+		}
+		if err == nil && tc.wantErr {
+			t.Errorf("compileSelector(%q): expected error, got none", tc.selector)
+		}
+		if tc.wantErr {
+			continue
+		}
+
+		if isLiteral := m == nil; isLiteral != tc.wantLiteral {
+			t.Errorf("compileSelector(%q) returned literal = %t, want %t", tc.selector, isLiteral, tc.wantLiteral)
+		}
+	}
+}
+
+func TestSplitFields(t *testing.T) {
+	tt := []struct {
+		s       string
+		n       int
+		want    []string
+		wantErr bool
+	}{
+		{s: "a:b", n: -1, want: []string{"a", "b"}},
+		{s: "a:b:c", n: -1, want: []string{"a", "b", "c"}},
+		{s: "a:b:c:d", n: 3, want: []string{"a", "b", "c:d"}},
+		{s: "a", n: 3, want: []string{"a"}},
+		{s: "/a:b/:c", n: -1, want: []string{"/a:b/", "c"}},
+		{s: "a:/(?:b|c)/", n: -1, want: []string{"a", "/(?:b|c)/"}},
+		{s: `/a\/:b/:c`, n: -1, want: []string{`/a\/:b/`, "c"}},
+		{s: "a:b:/c:d", n: 3, want: []string{"a", "b", "/c:d"}},
+		{s: "/a:b", n: -1, wantErr: true},
+	}
+
+	for _, tc := range tt {
+		actual, err := splitFields(tc.s, tc.n)
+
+		if err != nil && !tc.wantErr {
+			t.Errorf("splitFields(%q, %d): unexpected error: %v", tc.s, tc.n, err)
+		}
+		if err == nil && tc.wantErr {
+			t.Errorf("splitFields(%q, %d): expected error, got none", tc.s, tc.n)
+		}
+		if tc.wantErr {
+			continue
+		}
+
+		if !slices.Equal(actual, tc.want) {
+			t.Errorf("splitFields(%q, %d) = %q, want %q", tc.s, tc.n, actual, tc.want)
+		}
+	}
+}
@@ -13,16 +13,21 @@ type whitespaceRule struct {
 }
 
 func parseWhitespaceRule(s string) (*whitespaceRule, error) {
-	parts := strings.Split(s, ":")
+	parts, err := splitFields(s, -1)
+	if err != nil {
+		return nil, err
+	}
 	if len(parts) != 2 {
 		return nil, errors.New("syntax error")
 	}
 
+	base, err := newBaseRule(parts[0], parts[1])
+	if err != nil {
+		return nil, err
+	}
+
 	r := whitespaceRule{
-		baseRule: baseRule{
-			resourceType: parts[0],
-			attribute:    parts[1],
-		},
+		baseRule: base,
 	}
 
 	return &r, nil
@@ -0,0 +1,85 @@
+package ignore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type yamlRule struct {
+	baseRule
+}
+
+func parseYAMLRule(s string) (*yamlRule, error) {
+	parts, err := splitFields(s, -1)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) != 2 {
+		return nil, errors.New("syntax error")
+	}
+
+	base, err := newBaseRule(parts[0], parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	r := yamlRule{
+		baseRule: base,
+	}
+
+	return &r, nil
+}
+
+func (r yamlRule) String() string {
+	return fmt.Sprintf("%s:%s:%s", RuleTypeYAML, r.resourceType, r.attribute)
+}
+
+// Equates parses both values as YAML streams and compares the results. If
+// either value is not a string containing valid YAML, the values do not match.
+func (r *yamlRule) Equates(a, b interface{}) bool {
+	aStr, ok := a.(string)
+	if !ok {
+		return false
+	}
+	bStr, ok := b.(string)
+	if !ok {
+		return false
+	}
+
+	aDocs, err := parseYAMLDocuments(aStr)
+	if err != nil {
+		return false
+	}
+	bDocs, err := parseYAMLDocuments(bStr)
+	if err != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(aDocs, bDocs)
+}
+
+// parseYAMLDocuments decodes every document in a YAML stream. Kubernetes
+// manifests often contain several documents separated by "---".
+func parseYAMLDocuments(s string) ([]interface{}, error) {
+	var docs []interface{}
+
+	dec := yaml.NewDecoder(strings.NewReader(s))
+	for {
+		var doc interface{}
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
@@ -0,0 +1,109 @@
+package ignore
+
+import "testing"
+
+func TestYAMLRuleAppliesTo(t *testing.T) {
+	rule := yamlRule{
+		baseRule{
+			resourceType: "my_resource",
+			attribute:    "my_attr",
+		},
+	}
+
+	tt := []struct {
+		resourceType string
+		attribute    string
+		want         bool
+	}{
+		{
+			resourceType: "my_resource",
+			attribute:    "my_attr",
+			want:         true,
+		},
+		{
+			resourceType: "not_my_resource",
+			attribute:    "my_attr",
+			want:         false,
+		},
+		{
+			resourceType: "my_resource",
+			attribute:    "not_my_attr",
+			want:         false,
+		},
+		{
+			resourceType: "not_my_resource",
+			attribute:    "not_my_attr",
+			want:         false,
+		},
+	}
+
+	for _, tc := range tt {
+		actual := rule.AppliesTo(tc.resourceType, tc.attribute)
+		if actual != tc.want {
+			t.Errorf("AppliesTo(%q, %q) = %t, want %t", tc.resourceType, tc.attribute, actual, tc.want)
+		}
+	}
+}
+
+func TestYAMLRuleEquates(t *testing.T) {
+	rule := yamlRule{
+		baseRule{
+			resourceType: "my_resource",
+			attribute:    "my_attr",
+		},
+	}
+
+	tt := []struct {
+		valueA interface{}
+		valueB interface{}
+		want   bool
+	}{
+		{
+			valueA: "foo: bar\n",
+			valueB: "foo: bar\n",
+			want:   true,
+		},
+		{
+			valueA: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: my-config\n",
+			valueB: "kind: ConfigMap\nmetadata: {name: my-config}\napiVersion: v1",
+			want:   true,
+		},
+		{
+			valueA: "apiVersion: v1\nkind: ConfigMap\n---\napiVersion: v1\nkind: Secret\n",
+			valueB: "kind: ConfigMap\napiVersion: v1\n---\nkind: Secret\napiVersion: v1\n",
+			want:   true,
+		},
+		{
+			valueA: "apiVersion: v1\nkind: ConfigMap\n---\napiVersion: v1\nkind: Secret\n",
+			valueB: "apiVersion: v1\nkind: ConfigMap\n",
+			want:   false,
+		},
+		{
+			valueA: "name: foo bar\n",
+			valueB: "name: foobar\n",
+			want:   false,
+		},
+		{
+			valueA: "foo: [a, b]\n",
+			valueB: "foo:\n  - a\n  - b\n",
+			want:   true,
+		},
+		{
+			valueA: "foo: bar\n",
+			valueB: "foo: [bar\n",
+			want:   false,
+		},
+		{
+			valueA: 123,
+			valueB: 123,
+			want:   false,
+		},
+	}
+
+	for _, tc := range tt {
+		actual := rule.Equates(tc.valueA, tc.valueB)
+		if actual != tc.want {
+			t.Errorf("Equates(%#v, %#v) = %t, want %t", tc.valueA, tc.valueB, actual, tc.want)
+		}
+	}
+}
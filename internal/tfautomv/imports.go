@@ -0,0 +1,41 @@
+package tfautomv
+
+import (
+	"fmt"
+
+	"github.com/busser/tfautomv/internal/terraform"
+)
+
+// ImportsFromMoves returns, for each move, the import that brings the resource
+// at the move's source under management at the move's destination. Imports
+// use the ID of the source resource in Terraform's state.
+func ImportsFromMoves(analysis *Analysis, moves []terraform.Move) ([]terraform.Import, error) {
+	type key struct{ workdir, address string }
+	destroyed := make(map[key]*Resource)
+	for _, resources := range analysis.DestroyedByType {
+		for _, r := range resources {
+			destroyed[key{r.Workdir, r.Address}] = r
+		}
+	}
+
+	var imports []terraform.Import
+	for _, m := range moves {
+		r, ok := destroyed[key{m.FromWorkdir, m.From}]
+		if !ok {
+			return nil, fmt.Errorf("cannot import %s: %s is not planned for destruction", m.To, m.From)
+		}
+
+		id, ok := r.Attributes["id"].(string)
+		if !ok || id == "" {
+			return nil, fmt.Errorf("cannot import %s: %s has no id attribute", m.To, m.From)
+		}
+
+		imports = append(imports, terraform.Import{
+			To:      m.To,
+			ID:      id,
+			Workdir: m.ToWorkdir,
+		})
+	}
+
+	return imports, nil
+}
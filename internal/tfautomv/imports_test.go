@@ -0,0 +1,78 @@
+package tfautomv
+
+import (
+	"reflect"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+
+	"github.com/busser/tfautomv/internal/terraform"
+)
+
+func TestImportsFromMoves(t *testing.T) {
+	plans := map[string]*tfjson.Plan{
+		"monolith": dummyPlanWithAttributes(t,
+			nil,
+			[]dummyResourceWithAttributes{
+				{"random_pet.alpha", "random_pet", map[string]interface{}{"id": "happy-cat"}},
+				{"random_pet.beta", "random_pet", map[string]interface{}{"length": 3}},
+			},
+		),
+	}
+
+	analysis, err := AnalysisFromPlans(plans, nil, AnalysisOptions{})
+	if err != nil {
+		t.Fatalf("AnalysisFromPlans(): %v", err)
+	}
+
+	tt := []struct {
+		name    string
+		moves   []terraform.Move
+		want    []terraform.Import
+		wantErr bool
+	}{
+		{
+			name: "resource with id",
+			moves: []terraform.Move{
+				{From: "random_pet.alpha", To: "random_pet.this", FromWorkdir: "monolith", ToWorkdir: "pets"},
+			},
+			want: []terraform.Import{
+				{To: "random_pet.this", ID: "happy-cat", Workdir: "pets"},
+			},
+		},
+		{
+			name: "resource without id",
+			moves: []terraform.Move{
+				{From: "random_pet.beta", To: "random_pet.this", FromWorkdir: "monolith", ToWorkdir: "pets"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown resource",
+			moves: []terraform.Move{
+				{From: "random_pet.alpha", To: "random_pet.this", FromWorkdir: "pets", ToWorkdir: "monolith"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := ImportsFromMoves(analysis, tc.moves)
+
+			if err != nil && !tc.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err == nil && tc.wantErr {
+				t.Fatalf("expected error, got none")
+			}
+			if tc.wantErr {
+				return
+			}
+
+			if !reflect.DeepEqual(actual, tc.want) {
+				t.Errorf("ImportsFromMoves() = %#v, want %#v", actual, tc.want)
+			}
+		})
+	}
+}
@@ -3,12 +3,26 @@ package tfautomv
 import (
 	"sort"
 
+	"github.com/busser/tfautomv/internal/assignment"
 	"github.com/busser/tfautomv/internal/terraform"
 )
 
+// MovesOptions configure how MovesFromAnalysis chooses moves.
+type MovesOptions struct {
+	// By default, resources with more than one match are not moved. When
+	// OptimalAssignment is true, those resources are paired so that the total
+	// score of all pairs is as high as possible. Moves chosen this way are
+	// flagged as low confidence.
+	OptimalAssignment bool
+
+	// MinScore is the lowest score two resources can have to be paired by the
+	// optimal assignment.
+	MinScore float64
+}
+
 // MovesFromAnalysis identifies which resources should be moved from one
 // address to the other in Terraform's state, based on the provided analysis.
-func MovesFromAnalysis(analysis *Analysis) []terraform.Move {
+func MovesFromAnalysis(analysis *Analysis, opts MovesOptions) []terraform.Move {
 
 	// We choose to move a resource planned for destruction to a resource
 	// planned for creation if and only if the resources match each other and
@@ -24,33 +38,169 @@ func MovesFromAnalysis(analysis *Analysis) []terraform.Move {
 	}
 
 	var moves []terraform.Move
+	moved := make(map[*Resource]bool)
 
-	for _, resources := range analysis.CreatedByType {
-		for _, created := range resources {
-			if matchCountByResource[created] != 1 {
-				continue
+	for _, created := range analysis.Created() {
+		if matchCountByResource[created] != 1 {
+			continue
+		}
+
+		var destroyed *Resource
+		for _, comp := range analysis.Comparisons[created] {
+			if comp.IsMatch() {
+				destroyed = comp.Destroyed
+			}
+		}
+
+		if matchCountByResource[destroyed] != 1 {
+			continue
+		}
+
+		m := terraform.Move{
+			From:        destroyed.Address,
+			To:          created.Address,
+			FromWorkdir: destroyed.Workdir,
+			ToWorkdir:   created.Workdir,
+		}
+		moves = append(moves, m)
+		moved[created] = true
+		moved[destroyed] = true
+	}
+
+	if opts.OptimalAssignment {
+		moves = append(moves, optimalMoves(analysis, moved, opts.MinScore)...)
+	}
+
+	sort.Sort(terraform.InOrder(moves))
+
+	return moves
+}
+
+// optimalMoves pairs resources that were not already moved so that the total
+// score of all pairs is as high as possible. Pairs with a score lower than
+// minScore are not allowed.
+func optimalMoves(analysis *Analysis, moved map[*Resource]bool, minScore float64) []terraform.Move {
+	var moves []terraform.Move
+
+	for _, types := range typeGroups(analysis) {
+		var created, destroyed []*Resource
+		for _, typ := range types {
+			created = append(created, analysis.CreatedByType[typ]...)
+			destroyed = append(destroyed, analysis.DestroyedByType[typ]...)
+		}
+		created = notMoved(created, moved)
+		destroyed = notMoved(destroyed, moved)
+		if len(created) == 0 || len(destroyed) == 0 {
+			continue
+		}
+
+		column := make(map[*Resource]int, len(destroyed))
+		for j, d := range destroyed {
+			column[d] = j
+		}
+
+		weights := make([][]float64, len(created))
+		for i, c := range created {
+			weights[i] = make([]float64, len(destroyed))
+			for j := range weights[i] {
+				weights[i][j] = -1
 			}
 
-			var destroyed *Resource
-			for _, comp := range analysis.Comparisons[created] {
-				if comp.IsMatch() {
-					destroyed = comp.Destroyed
+			for _, comp := range analysis.Comparisons[c] {
+				j, ok := column[comp.Destroyed]
+				if !ok {
+					continue
+				}
+				// Inconclusive comparisons failed a safety check, so their
+				// resources must not be paired, whatever their score.
+				if comp.Inconclusive != "" {
+					continue
+				}
+				if score := comp.Score(); score >= minScore {
+					weights[i][j] = score
 				}
 			}
+		}
 
-			if matchCountByResource[destroyed] != 1 {
+		for i, j := range assignment.Maximize(weights) {
+			if j < 0 {
 				continue
 			}
+			moves = append(moves, terraform.Move{
+				From:          destroyed[j].Address,
+				To:            created[i].Address,
+				FromWorkdir:   destroyed[j].Workdir,
+				ToWorkdir:     created[i].Workdir,
+				LowConfidence: true,
+			})
+		}
+	}
+
+	return moves
+}
+
+// typeGroups splits the analysis' types into groups, so that resources of
+// types in different groups are never compared with each other. Each type is
+// alone in its group, unless it is equivalent to other types.
+func typeGroups(analysis *Analysis) [][]string {
+	// Each type points to another type of its group, or to itself if it
+	// represents the group.
+	parent := make(map[string]string)
+	var root func(typ string) string
+	root = func(typ string) string {
+		if p, ok := parent[typ]; ok && p != typ {
+			r := root(p)
+			parent[typ] = r
+			return r
+		}
+		return typ
+	}
 
-			m := terraform.Move{
-				From: destroyed.Address,
-				To:   created.Address,
+	for _, created := range analysis.Created() {
+		for _, comp := range analysis.Comparisons[created] {
+			a, b := root(created.Type), root(comp.Destroyed.Type)
+			if a == b {
+				continue
+			}
+			if b < a {
+				a, b = b, a
 			}
-			moves = append(moves, m)
+			parent[b] = a
 		}
 	}
 
-	sort.Sort(terraform.InOrder(moves))
+	var groups [][]string
+	index := make(map[string]int)
+	for _, typ := range analysis.Types() {
+		r := root(typ)
+		i, ok := index[r]
+		if !ok {
+			i = len(groups)
+			index[r] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], typ)
+	}
 
-	return moves
+	return groups
+}
+
+// notMoved returns the resources that are not in moved, sorted by address so
+// that ties are always broken the same way.
+func notMoved(resources []*Resource, moved map[*Resource]bool) []*Resource {
+	var result []*Resource
+	for _, r := range resources {
+		if !moved[r] {
+			result = append(result, r)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Workdir != result[j].Workdir {
+			return result[i].Workdir < result[j].Workdir
+		}
+		return result[i].Address < result[j].Address
+	})
+
+	return result
 }
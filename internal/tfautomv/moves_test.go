@@ -0,0 +1,315 @@
+package tfautomv
+
+import (
+	"reflect"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+
+	"github.com/busser/tfautomv/internal/terraform"
+)
+
+type dummyResourceWithAttributes struct {
+	address    string
+	typ        string
+	attributes map[string]interface{}
+}
+
+func dummyPlanWithAttributes(t *testing.T, created, destroyed []dummyResourceWithAttributes) *tfjson.Plan {
+	t.Helper()
+
+	var plan tfjson.Plan
+
+	for _, r := range created {
+		plan.ResourceChanges = append(plan.ResourceChanges, &tfjson.ResourceChange{
+			Address: r.address,
+			Type:    r.typ,
+			Change: &tfjson.Change{
+				Actions: []tfjson.Action{tfjson.ActionCreate},
+				After:   r.attributes,
+			},
+		})
+	}
+	for _, r := range destroyed {
+		plan.ResourceChanges = append(plan.ResourceChanges, &tfjson.ResourceChange{
+			Address: r.address,
+			Type:    r.typ,
+			Change: &tfjson.Change{
+				Actions: []tfjson.Action{tfjson.ActionDelete},
+				Before:  r.attributes,
+			},
+		})
+	}
+
+	return &plan
+}
+
+func TestMovesFromAnalysis(t *testing.T) {
+	tt := []struct {
+		name         string
+		created      []dummyResourceWithAttributes
+		destroyed    []dummyResourceWithAttributes
+		analysisOpts AnalysisOptions
+		opts         MovesOptions
+		want         []terraform.Move
+	}{
+		{
+			name: "single match",
+			created: []dummyResourceWithAttributes{
+				{"random_pet.alpha", "random_pet", map[string]interface{}{"length": 2}},
+				{"random_pet.beta", "random_pet", map[string]interface{}{"length": 3}},
+			},
+			destroyed: []dummyResourceWithAttributes{
+				{"random_pet.first", "random_pet", map[string]interface{}{"length": 2}},
+				{"random_pet.second", "random_pet", map[string]interface{}{"length": 3}},
+			},
+			want: []terraform.Move{
+				{From: "random_pet.first", To: "random_pet.alpha"},
+				{From: "random_pet.second", To: "random_pet.beta"},
+			},
+		},
+		{
+			name: "multiple matches",
+			created: []dummyResourceWithAttributes{
+				{"random_pet.alpha", "random_pet", map[string]interface{}{"length": 2}},
+				{"random_pet.beta", "random_pet", map[string]interface{}{"length": 2}},
+			},
+			destroyed: []dummyResourceWithAttributes{
+				{"random_pet.first", "random_pet", map[string]interface{}{"length": 2}},
+				{"random_pet.second", "random_pet", map[string]interface{}{"length": 2}},
+			},
+			want: nil,
+		},
+		{
+			name: "multiple matches with optimal assignment",
+			created: []dummyResourceWithAttributes{
+				{"random_pet.alpha", "random_pet", map[string]interface{}{"length": 2}},
+				{"random_pet.beta", "random_pet", map[string]interface{}{"length": 2}},
+			},
+			destroyed: []dummyResourceWithAttributes{
+				{"random_pet.first", "random_pet", map[string]interface{}{"length": 2}},
+				{"random_pet.second", "random_pet", map[string]interface{}{"length": 2}},
+			},
+			opts: MovesOptions{
+				OptimalAssignment: true,
+				MinScore:          0.5,
+			},
+			want: []terraform.Move{
+				{From: "random_pet.first", To: "random_pet.alpha", LowConfidence: true},
+				{From: "random_pet.second", To: "random_pet.beta", LowConfidence: true},
+			},
+		},
+		{
+			name: "optimal assignment below minimum score",
+			created: []dummyResourceWithAttributes{
+				{"random_pet.alpha", "random_pet", map[string]interface{}{"length": 2, "separator": "-"}},
+				{"random_pet.beta", "random_pet", map[string]interface{}{"length": 3, "separator": "-"}},
+			},
+			destroyed: []dummyResourceWithAttributes{
+				{"random_pet.first", "random_pet", map[string]interface{}{"length": 2, "separator": "+"}},
+				{"random_pet.second", "random_pet", map[string]interface{}{"length": 4, "separator": "+"}},
+			},
+			opts: MovesOptions{
+				OptimalAssignment: true,
+				MinScore:          0.5,
+			},
+			want: []terraform.Move{
+				{From: "random_pet.first", To: "random_pet.alpha", LowConfidence: true},
+			},
+		},
+		{
+			name: "optimal assignment keeps certain moves",
+			created: []dummyResourceWithAttributes{
+				{"random_pet.alpha", "random_pet", map[string]interface{}{"length": 2, "separator": "-"}},
+				{"random_pet.beta", "random_pet", map[string]interface{}{"length": 3, "separator": "-"}},
+				{"random_pet.gamma", "random_pet", map[string]interface{}{"length": 3, "separator": "-"}},
+			},
+			destroyed: []dummyResourceWithAttributes{
+				{"random_pet.first", "random_pet", map[string]interface{}{"length": 2, "separator": "-"}},
+				{"random_pet.second", "random_pet", map[string]interface{}{"length": 3, "separator": "+"}},
+				{"random_pet.third", "random_pet", map[string]interface{}{"length": 3, "separator": "-"}},
+			},
+			opts: MovesOptions{
+				OptimalAssignment: true,
+				MinScore:          0.5,
+			},
+			want: []terraform.Move{
+				{From: "random_pet.first", To: "random_pet.alpha"},
+				{From: "random_pet.second", To: "random_pet.gamma", LowConfidence: true},
+				{From: "random_pet.third", To: "random_pet.beta", LowConfidence: true},
+			},
+		},
+		{
+			name: "optimal assignment between equivalent types",
+			created: []dummyResourceWithAttributes{
+				{"terraform_data.alpha", "terraform_data", map[string]interface{}{"triggers_replace": map[string]interface{}{"version": "1"}}},
+				{"terraform_data.beta", "terraform_data", map[string]interface{}{"triggers_replace": map[string]interface{}{"version": "1"}}},
+			},
+			destroyed: []dummyResourceWithAttributes{
+				{"null_resource.first", "null_resource", map[string]interface{}{"triggers": map[string]interface{}{"version": "1"}}},
+				{"null_resource.second", "null_resource", map[string]interface{}{"triggers": map[string]interface{}{"version": "1"}}},
+			},
+			analysisOpts: AnalysisOptions{
+				TypeEquivalences: []TypeEquivalence{
+					{From: "null_resource", To: "terraform_data", Attributes: map[string]string{"triggers": "triggers_replace"}},
+				},
+			},
+			opts: MovesOptions{
+				OptimalAssignment: true,
+				MinScore:          0.5,
+			},
+			want: []terraform.Move{
+				{From: "null_resource.first", To: "terraform_data.alpha", LowConfidence: true},
+				{From: "null_resource.second", To: "terraform_data.beta", LowConfidence: true},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			plan := dummyPlanWithAttributes(t, tc.created, tc.destroyed)
+
+			analysis, err := AnalysisFromPlan(plan, nil, tc.analysisOpts)
+			if err != nil {
+				t.Fatalf("AnalysisFromPlan(): unexpected error: %v", err)
+			}
+
+			actual := MovesFromAnalysis(analysis, tc.opts)
+
+			if len(actual) != len(tc.want) {
+				t.Fatalf("MovesFromAnalysis() = %#v, want %#v", actual, tc.want)
+			}
+			for i := range actual {
+				if actual[i] != tc.want[i] {
+					t.Errorf("MovesFromAnalysis() = %#v, want %#v", actual, tc.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestMovesFromAnalysisAcrossStates(t *testing.T) {
+	plans := map[string]*tfjson.Plan{
+		"monolith": dummyPlanWithAttributes(t,
+			nil,
+			[]dummyResourceWithAttributes{
+				{"random_pet.alpha", "random_pet", map[string]interface{}{"length": 2}},
+				{"random_pet.beta", "random_pet", map[string]interface{}{"length": 3}},
+			},
+		),
+		"pets": dummyPlanWithAttributes(t,
+			[]dummyResourceWithAttributes{
+				{"random_pet.alpha", "random_pet", map[string]interface{}{"length": 2}},
+				{"random_pet.gamma", "random_pet", map[string]interface{}{"length": 3}},
+			},
+			nil,
+		),
+	}
+
+	analysis, err := AnalysisFromPlans(plans, nil, AnalysisOptions{})
+	if err != nil {
+		t.Fatalf("AnalysisFromPlans(): %v", err)
+	}
+
+	actual := MovesFromAnalysis(analysis, MovesOptions{})
+
+	want := []terraform.Move{
+		{From: "random_pet.alpha", To: "random_pet.alpha", FromWorkdir: "monolith", ToWorkdir: "pets"},
+		{From: "random_pet.beta", To: "random_pet.gamma", FromWorkdir: "monolith", ToWorkdir: "pets"},
+	}
+
+	if !reflect.DeepEqual(actual, want) {
+		t.Errorf("MovesFromAnalysis() = %#v, want %#v", actual, want)
+	}
+}
+
+func TestMovesFromAnalysisOptimalInconclusive(t *testing.T) {
+	change := func(addr, provider string, actions tfjson.Actions, before, after, afterUnknown map[string]interface{}) *tfjson.ResourceChange {
+		return &tfjson.ResourceChange{
+			Address:      addr,
+			Mode:         tfjson.ManagedResourceMode,
+			Type:         "random_pet",
+			Name:         addr[len("random_pet."):],
+			ProviderName: provider,
+			Change: &tfjson.Change{
+				Actions:      actions,
+				Before:       before,
+				After:        after,
+				AfterUnknown: afterUnknown,
+			},
+		}
+	}
+	const random = "registry.terraform.io/hashicorp/random"
+	create := tfjson.Actions{tfjson.ActionCreate}
+	destroy := tfjson.Actions{tfjson.ActionDelete}
+	replace := tfjson.Actions{tfjson.ActionDelete, tfjson.ActionCreate}
+	attrs := map[string]interface{}{"length": 2}
+
+	tt := []struct {
+		name    string
+		changes []*tfjson.ResourceChange
+		opts    AnalysisOptions
+	}{
+		{
+			name: "different providers",
+			changes: []*tfjson.ResourceChange{
+				change("random_pet.new", random, create, nil, attrs, nil),
+				change("random_pet.old", "registry.example.com/acme/random", destroy, attrs, nil, nil),
+			},
+		},
+		{
+			name: "different providers and mismatching attributes",
+			changes: []*tfjson.ResourceChange{
+				change("random_pet.new", random, create, nil, map[string]interface{}{"length": 2, "prefix": "a"}, nil),
+				change("random_pet.old", "registry.example.com/acme/random", destroy, map[string]interface{}{"length": 2, "prefix": "b"}, nil, nil),
+			},
+		},
+		{
+			name: "not enough matching attributes",
+			changes: []*tfjson.ResourceChange{
+				change("random_pet.new", random, create, nil, map[string]interface{}{}, nil),
+				change("random_pet.old", random, destroy, map[string]interface{}{}, nil, nil),
+			},
+			opts: AnalysisOptions{MinMatching: Threshold{Count: 1}},
+		},
+		{
+			name: "replaced resource planned for creation",
+			changes: []*tfjson.ResourceChange{
+				change("random_pet.new", random, replace, map[string]interface{}{"length": 3}, attrs, nil),
+				change("random_pet.old", random, destroy, attrs, nil, nil),
+			},
+		},
+		{
+			name: "replaced resource planned for destruction",
+			changes: []*tfjson.ResourceChange{
+				change("random_pet.new", random, create, nil, attrs, nil),
+				change("random_pet.old", random, replace, attrs, map[string]interface{}{"length": 3}, nil),
+			},
+		},
+		{
+			name: "strict unknowns",
+			changes: []*tfjson.ResourceChange{
+				change("random_pet.new", random, create, nil, attrs, map[string]interface{}{"id": true, "prefix": true}),
+				change("random_pet.old", random, destroy, map[string]interface{}{"length": 2, "id": "old", "prefix": "old"}, nil, nil),
+			},
+			opts: AnalysisOptions{StrictUnknowns: true},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			plan := &tfjson.Plan{ResourceChanges: tc.changes}
+
+			analysis, err := AnalysisFromPlan(plan, nil, tc.opts)
+			if err != nil {
+				t.Fatalf("AnalysisFromPlan(): unexpected error: %v", err)
+			}
+
+			moves := MovesFromAnalysis(analysis, MovesOptions{OptimalAssignment: true})
+			if len(moves) != 0 {
+				t.Errorf("MovesFromAnalysis() = %#v, want no moves", moves)
+			}
+		})
+	}
+}
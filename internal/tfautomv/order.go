@@ -0,0 +1,101 @@
+package tfautomv
+
+import "sort"
+
+// The analysis indexes resources and comparisons with maps, so iterating over
+// them directly yields a different order every time. The methods below list
+// them in a stable order instead, so that the same analysis is always
+// displayed the same way.
+
+// Types returns the types of all resources in the analysis, sorted.
+func (a *Analysis) Types() []string {
+	seen := make(map[string]bool)
+	var types []string
+	for _, byType := range []map[string][]*Resource{a.CreatedByType, a.DestroyedByType} {
+		for typ := range byType {
+			if !seen[typ] {
+				seen[typ] = true
+				types = append(types, typ)
+			}
+		}
+	}
+
+	sort.Strings(types)
+
+	return types
+}
+
+// Created returns the resources planned for creation, sorted by type and then
+// by address.
+func (a *Analysis) Created() []*Resource {
+	return sortedResources(a.Types(), a.CreatedByType)
+}
+
+// Destroyed returns the resources planned for destruction, sorted by type and
+// then by address.
+func (a *Analysis) Destroyed() []*Resource {
+	return sortedResources(a.Types(), a.DestroyedByType)
+}
+
+func sortedResources(types []string, byType map[string][]*Resource) []*Resource {
+	var resources []*Resource
+	for _, typ := range types {
+		ofType := make([]*Resource, len(byType[typ]))
+		copy(ofType, byType[typ])
+		sort.Slice(ofType, func(i, j int) bool {
+			return lessResource(ofType[i], ofType[j])
+		})
+		resources = append(resources, ofType...)
+	}
+	return resources
+}
+
+// SortedComparisons returns the comparisons between r and other resources.
+// Matches come first, then inconclusive comparisons, then mismatches. Within
+// each group, comparisons are sorted by the other resource's type and address.
+func (a *Analysis) SortedComparisons(r *Resource) []Comparison {
+	comps := make([]Comparison, len(a.Comparisons[r]))
+	copy(comps, a.Comparisons[r])
+
+	other := func(c *Comparison) *Resource {
+		if c.Created == r {
+			return c.Destroyed
+		}
+		return c.Created
+	}
+
+	sort.SliceStable(comps, func(i, j int) bool {
+		si, sj := matchStatus(&comps[i]), matchStatus(&comps[j])
+		if si != sj {
+			return si < sj
+		}
+		oi, oj := other(&comps[i]), other(&comps[j])
+		if oi.Type != oj.Type {
+			return oi.Type < oj.Type
+		}
+		return lessResource(oi, oj)
+	})
+
+	return comps
+}
+
+// matchStatus ranks comparisons: matches first, then inconclusive comparisons,
+// then mismatches.
+func matchStatus(c *Comparison) int {
+	switch {
+	case c.IsMatch():
+		return 0
+	case len(c.MismatchingAttributes) == 0:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// lessResource orders resources by working directory and then by address.
+func lessResource(a, b *Resource) bool {
+	if a.Workdir != b.Workdir {
+		return a.Workdir < b.Workdir
+	}
+	return a.Address < b.Address
+}
@@ -0,0 +1,68 @@
+package tfautomv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalysisOrder(t *testing.T) {
+	petB := &Resource{Type: "random_pet", Address: "random_pet.b"}
+	petA := &Resource{Type: "random_pet", Address: "random_pet.a"}
+	petOtherDir := &Resource{Type: "random_pet", Address: "random_pet.a", Workdir: "other"}
+	id := &Resource{Type: "random_id", Address: "random_id.z"}
+	destroyedA := &Resource{Type: "random_pet", Address: "random_pet.x"}
+	destroyedB := &Resource{Type: "random_pet", Address: "random_pet.y"}
+	destroyedC := &Resource{Type: "random_pet", Address: "random_pet.z"}
+	password := &Resource{Type: "random_password", Address: "random_password.a"}
+
+	analysis := &Analysis{
+		CreatedByType: map[string][]*Resource{
+			"random_pet": {petOtherDir, petB, petA},
+			"random_id":  {id},
+		},
+		DestroyedByType: map[string][]*Resource{
+			"random_pet":      {destroyedC, destroyedB, destroyedA},
+			"random_password": {password},
+		},
+		Comparisons: map[*Resource][]Comparison{
+			petA: {
+				{Created: petA, Destroyed: destroyedC, MismatchingAttributes: []string{"length"}},
+				{Created: petA, Destroyed: destroyedB, MismatchingAttributes: []string{"length"}},
+				{Created: petA, Destroyed: destroyedA, Inconclusive: "not enough attributes match"},
+				{Created: petA, Destroyed: destroyedC, Pinned: true},
+			},
+		},
+	}
+
+	wantTypes := []string{"random_id", "random_password", "random_pet"}
+	if got := analysis.Types(); !reflect.DeepEqual(got, wantTypes) {
+		t.Errorf("Types() = %v, want %v", got, wantTypes)
+	}
+
+	wantCreated := []*Resource{id, petA, petB, petOtherDir}
+	if got := analysis.Created(); !reflect.DeepEqual(got, wantCreated) {
+		t.Errorf("Created() = %v, want %v", addresses(got), addresses(wantCreated))
+	}
+
+	wantDestroyed := []*Resource{password, destroyedA, destroyedB, destroyedC}
+	if got := analysis.Destroyed(); !reflect.DeepEqual(got, wantDestroyed) {
+		t.Errorf("Destroyed() = %v, want %v", addresses(got), addresses(wantDestroyed))
+	}
+
+	var gotOthers []string
+	for _, comp := range analysis.SortedComparisons(petA) {
+		gotOthers = append(gotOthers, comp.Destroyed.Address)
+	}
+	wantOthers := []string{"random_pet.z", "random_pet.x", "random_pet.y", "random_pet.z"}
+	if !reflect.DeepEqual(gotOthers, wantOthers) {
+		t.Errorf("SortedComparisons() = %v, want %v", gotOthers, wantOthers)
+	}
+}
+
+func addresses(resources []*Resource) []string {
+	var addrs []string
+	for _, r := range resources {
+		addrs = append(addrs, r.Workdir+":"+r.Address)
+	}
+	return addrs
+}
@@ -0,0 +1,181 @@
+package tfautomv
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A Pairing is a decision, made by a human, about whether a resource planned
+// for destruction should be moved to a resource planned for creation.
+type Pairing struct {
+	// Address of the resource planned for destruction.
+	From string
+
+	// Address of the resource planned for creation.
+	To string
+
+	// Never is true when From must not be moved to To. Otherwise, From must be
+	// moved to To.
+	Never bool
+}
+
+// ParsePairings reads pairings, one per line. Each line is either
+// "FROM -> TO", to pair the resources, or "never FROM -> TO", to prevent it.
+// Blank lines and lines starting with "#" are ignored.
+func ParsePairings(r io.Reader) ([]Pairing, error) {
+	var pairings []Pairing
+
+	pinnedFrom := make(map[string]string)
+	pinnedTo := make(map[string]string)
+	never := make(map[[2]string]bool)
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var p Pairing
+		if rest := strings.TrimPrefix(line, "never "); rest != line {
+			p.Never = true
+			line = strings.TrimSpace(rest)
+		}
+
+		from, to, ok := strings.Cut(line, "->")
+		p.From, p.To = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !ok || p.From == "" || p.To == "" {
+			return nil, fmt.Errorf("line %d: expected \"FROM -> TO\" or \"never FROM -> TO\", got %q", n, scanner.Text())
+		}
+
+		pair := [2]string{p.From, p.To}
+		switch {
+		case p.Never && pinnedFrom[p.From] == p.To:
+			return nil, fmt.Errorf("line %d: %s is already paired with %s", n, p.From, p.To)
+		case !p.Never && never[pair]:
+			return nil, fmt.Errorf("line %d: %s must never be paired with %s", n, p.From, p.To)
+		case !p.Never && pinnedFrom[p.From] != "" && pinnedFrom[p.From] != p.To:
+			return nil, fmt.Errorf("line %d: %s is already paired with %s", n, p.From, pinnedFrom[p.From])
+		case !p.Never && pinnedTo[p.To] != "" && pinnedTo[p.To] != p.From:
+			return nil, fmt.Errorf("line %d: %s is already paired with %s", n, p.To, pinnedTo[p.To])
+		}
+
+		if p.Never {
+			never[pair] = true
+		} else {
+			pinnedFrom[p.From] = p.To
+			pinnedTo[p.To] = p.From
+		}
+
+		pairings = append(pairings, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return pairings, nil
+}
+
+// ApplyPairings changes the analysis so that resources are paired as the
+// pairings require. Resources paired together are removed from all other
+// comparisons, which can leave other resources with a single match.
+//
+// Pairings that mention resources not planned for creation or destruction are
+// ignored, since they usually describe moves that were already made. They can
+// also be typos, so ApplyPairings returns them for the caller to report.
+func (a *Analysis) ApplyPairings(pairings []Pairing) (ignored []Pairing, err error) {
+	created := resourcesByAddress(a.CreatedByType)
+	destroyed := resourcesByAddress(a.DestroyedByType)
+
+	for _, p := range pairings {
+		from, err := onlyResource(destroyed[p.From])
+		if err != nil {
+			return nil, err
+		}
+		to, err := onlyResource(created[p.To])
+		if err != nil {
+			return nil, err
+		}
+		if from == nil || to == nil {
+			ignored = append(ignored, p)
+			continue
+		}
+
+		if from.Type != to.Type && !a.compared(to, from) {
+			return nil, fmt.Errorf("cannot pair %s with %s: their types are not equivalent", p.From, p.To)
+		}
+
+		if p.Never {
+			a.Exclude(to, from)
+			continue
+		}
+
+		// A resource planned for replacement still exists in the state and
+		// in the configuration, so nothing can move to or from its address.
+		switch {
+		case to.Replaced:
+			return nil, fmt.Errorf("cannot pair %s with %s: %s is planned for replacement", p.From, p.To, p.To)
+		case from.Replaced:
+			return nil, fmt.Errorf("cannot pair %s with %s: %s is planned for replacement", p.From, p.To, p.From)
+		}
+
+		if err := a.Pin(to, from); err != nil {
+			return nil, err
+		}
+	}
+
+	return ignored, nil
+}
+
+// compared returns whether the analysis compared the two resources.
+func (a *Analysis) compared(created, destroyed *Resource) bool {
+	for _, comp := range a.Comparisons[created] {
+		if comp.Destroyed == destroyed {
+			return true
+		}
+	}
+	return false
+}
+
+// Exclude prevents two resources from being paired by removing their
+// comparison from the analysis.
+func (a *Analysis) Exclude(created, destroyed *Resource) {
+	a.Comparisons[created] = withoutComparison(a.Comparisons[created], created, destroyed)
+	a.Comparisons[destroyed] = withoutComparison(a.Comparisons[destroyed], created, destroyed)
+}
+
+func withoutComparison(comps []Comparison, created, destroyed *Resource) []Comparison {
+	var kept []Comparison
+	for _, c := range comps {
+		if c.Created != created || c.Destroyed != destroyed {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+func resourcesByAddress(byType map[string][]*Resource) map[string][]*Resource {
+	index := make(map[string][]*Resource)
+	for _, resources := range byType {
+		for _, r := range resources {
+			index[r.Address] = append(index[r.Address], r)
+		}
+	}
+	return index
+}
+
+// onlyResource returns the only resource in resources, or nil if there is
+// none. Pairings only contain addresses, so they cannot refer to an address
+// present in several working directories.
+func onlyResource(resources []*Resource) (*Resource, error) {
+	switch len(resources) {
+	case 0:
+		return nil, nil
+	case 1:
+		return resources[0], nil
+	default:
+		return nil, fmt.Errorf("%s is in several working directories, so pairings cannot refer to it", resources[0].Address)
+	}
+}
@@ -0,0 +1,236 @@
+package tfautomv
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+
+	"github.com/busser/tfautomv/internal/terraform"
+)
+
+func TestParsePairings(t *testing.T) {
+	tt := []struct {
+		name    string
+		input   string
+		want    []Pairing
+		wantErr bool
+	}{
+		{
+			name:  "empty",
+			input: "",
+			want:  nil,
+		},
+		{
+			name: "pairs",
+			input: `# Decided during the network refactoring.
+random_pet.first -> random_pet.alpha
+
+never random_pet.second -> random_pet.alpha
+module.a["x"].random_pet.this->module.b["x"].random_pet.this
+`,
+			want: []Pairing{
+				{From: "random_pet.first", To: "random_pet.alpha"},
+				{From: "random_pet.second", To: "random_pet.alpha", Never: true},
+				{From: `module.a["x"].random_pet.this`, To: `module.b["x"].random_pet.this`},
+			},
+		},
+		{
+			name:    "missing arrow",
+			input:   "random_pet.first random_pet.alpha",
+			wantErr: true,
+		},
+		{
+			name:    "missing address",
+			input:   "never random_pet.first ->",
+			wantErr: true,
+		},
+		{
+			name: "same resource paired twice",
+			input: `random_pet.first -> random_pet.alpha
+random_pet.first -> random_pet.beta`,
+			wantErr: true,
+		},
+		{
+			name: "pair contradicts never pair",
+			input: `never random_pet.first -> random_pet.alpha
+random_pet.first -> random_pet.alpha`,
+			wantErr: true,
+		},
+		{
+			name: "never pair contradicts pair",
+			input: `random_pet.first -> random_pet.alpha
+never random_pet.first -> random_pet.alpha`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParsePairings(strings.NewReader(tc.input))
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("ParsePairings() should have returned an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePairings() returned error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ParsePairings() = %#v, want %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestApplyPairings(t *testing.T) {
+	tt := []struct {
+		name        string
+		pairings    []Pairing
+		want        []terraform.Move
+		wantIgnored []Pairing
+		wantErr     bool
+	}{
+		{
+			name: "no pairings",
+			want: nil,
+		},
+		{
+			name: "pair resolves ambiguity",
+			pairings: []Pairing{
+				{From: "random_pet.second", To: "random_pet.alpha"},
+			},
+			want: []terraform.Move{
+				{From: "random_pet.first", To: "random_pet.beta"},
+				{From: "random_pet.second", To: "random_pet.alpha"},
+			},
+		},
+		{
+			name: "never pairs resolve ambiguity",
+			pairings: []Pairing{
+				{From: "random_pet.second", To: "random_pet.alpha", Never: true},
+				{From: "random_pet.first", To: "random_pet.beta", Never: true},
+			},
+			want: []terraform.Move{
+				{From: "random_pet.first", To: "random_pet.alpha"},
+				{From: "random_pet.second", To: "random_pet.beta"},
+			},
+		},
+		{
+			name: "pair of mismatching resources",
+			pairings: []Pairing{
+				{From: "random_pet.third", To: "random_pet.alpha"},
+			},
+			want: []terraform.Move{
+				{From: "random_pet.third", To: "random_pet.alpha"},
+			},
+		},
+		{
+			name: "single never pair",
+			pairings: []Pairing{
+				{From: "random_pet.second", To: "random_pet.alpha", Never: true},
+			},
+			want: nil,
+		},
+		{
+			name: "unknown resources",
+			pairings: []Pairing{
+				{From: "random_pet.old", To: "random_pet.alpha"},
+				{From: "random_pet.first", To: "random_pet.typo", Never: true},
+			},
+			want: nil,
+			wantIgnored: []Pairing{
+				{From: "random_pet.old", To: "random_pet.alpha"},
+				{From: "random_pet.first", To: "random_pet.typo", Never: true},
+			},
+		},
+		{
+			name: "different types",
+			pairings: []Pairing{
+				{From: "random_id.fourth", To: "random_pet.alpha"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "pair excluded by never pair",
+			pairings: []Pairing{
+				{From: "random_pet.second", To: "random_pet.alpha", Never: true},
+				{From: "random_pet.second", To: "random_pet.alpha"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "replaced resource",
+			pairings: []Pairing{
+				{From: "random_pet.third", To: "random_pet.gamma"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "pair from replaced resource",
+			pairings: []Pairing{
+				{From: "random_pet.gamma", To: "random_pet.alpha"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "never pair with replaced resource",
+			pairings: []Pairing{
+				{From: "random_pet.third", To: "random_pet.gamma", Never: true},
+			},
+			want: nil,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			plan := dummyPlanWithAttributes(t,
+				[]dummyResourceWithAttributes{
+					{"random_pet.alpha", "random_pet", map[string]interface{}{"length": 2}},
+					{"random_pet.beta", "random_pet", map[string]interface{}{"length": 2}},
+				},
+				[]dummyResourceWithAttributes{
+					{"random_pet.first", "random_pet", map[string]interface{}{"length": 2}},
+					{"random_pet.second", "random_pet", map[string]interface{}{"length": 2}},
+					{"random_pet.third", "random_pet", map[string]interface{}{"length": 3}},
+					{"random_id.fourth", "random_id", map[string]interface{}{"length": 2}},
+				},
+			)
+			plan.ResourceChanges = append(plan.ResourceChanges, &tfjson.ResourceChange{
+				Address: "random_pet.gamma",
+				Type:    "random_pet",
+				Change: &tfjson.Change{
+					Actions: []tfjson.Action{tfjson.ActionDelete, tfjson.ActionCreate},
+					Before:  map[string]interface{}{"length": 5},
+					After:   map[string]interface{}{"length": 3},
+				},
+			})
+
+			analysis, err := AnalysisFromPlan(plan, nil, AnalysisOptions{})
+			if err != nil {
+				t.Fatalf("AnalysisFromPlan() returned error: %v", err)
+			}
+
+			ignored, err := analysis.ApplyPairings(tc.pairings)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("ApplyPairings() should have returned an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyPairings() returned error: %v", err)
+			}
+			if !reflect.DeepEqual(ignored, tc.wantIgnored) {
+				t.Errorf("ApplyPairings() ignored %v, want %v", ignored, tc.wantIgnored)
+			}
+
+			got := MovesFromAnalysis(analysis, MovesOptions{})
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("MovesFromAnalysis() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
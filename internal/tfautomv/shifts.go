@@ -0,0 +1,101 @@
+package tfautomv
+
+import (
+	"fmt"
+	"sort"
+)
+
+// IndexShifts lists the resources, without their instance keys, whose
+// instances shifted to other indices. This happens when an element is removed
+// from the middle of the list a resource's count is based on: each following
+// instance is replaced by the next one. Moves cannot fix this, since the
+// indices are still in use, but using for_each instead of count can.
+func (a *Analysis) IndexShifts() []string {
+	seen := make(map[string]bool)
+	var shifted []string
+
+	for _, created := range a.Created() {
+		for _, comp := range a.Comparisons[created] {
+			if !created.Replaced || len(comp.MismatchingAttributes) > 0 || !isIndexShift(comp.Created, comp.Destroyed) {
+				continue
+			}
+
+			addr := created.ResourceAddress()
+			if created.Workdir != "" {
+				addr += " (in " + created.Workdir + ")"
+			}
+			if !seen[addr] {
+				seen[addr] = true
+				shifted = append(shifted, addr)
+			}
+		}
+	}
+
+	sort.Strings(shifted)
+
+	return shifted
+}
+
+// isIndexShift returns whether c and d are different instances of the same
+// resource, identified by their index or key.
+func isIndexShift(c, d *Resource) bool {
+	return c.Index != nil && d.Index != nil &&
+		c.Workdir == d.Workdir &&
+		c.ModuleAddress == d.ModuleAddress &&
+		c.Mode == d.Mode &&
+		c.Type == d.Type &&
+		c.Name == d.Name
+}
+
+// preferSiblings resolves ambiguities between instances of resources that use
+// count or for_each. When replacing count with for_each, each instance should
+// move to a new key of the same resource, but it may also match instances of
+// other resources with the same attributes. When an instance matches exactly
+// one instance of its own resource, and that instance matches it back, their
+// other matches are made inconclusive.
+func (a *Analysis) preferSiblings() {
+	siblings := make(map[*Resource][]*Resource)
+	for _, created := range a.Created() {
+		for _, comp := range a.Comparisons[created] {
+			if comp.IsMatch() && isIndexShift(comp.Created, comp.Destroyed) {
+				siblings[comp.Created] = append(siblings[comp.Created], comp.Destroyed)
+				siblings[comp.Destroyed] = append(siblings[comp.Destroyed], comp.Created)
+			}
+		}
+	}
+
+	for _, created := range a.Created() {
+		if len(siblings[created]) != 1 {
+			continue
+		}
+		destroyed := siblings[created][0]
+		if len(siblings[destroyed]) != 1 {
+			continue
+		}
+
+		for _, pair := range [][2]*Resource{{created, destroyed}, {destroyed, created}} {
+			r, sibling := pair[0], pair[1]
+			for _, comp := range a.Comparisons[r] {
+				isPair := comp.Created == created && comp.Destroyed == destroyed
+				if isPair || !comp.IsMatch() {
+					continue
+				}
+				reason := fmt.Sprintf("%s matches %s, another instance of its own resource", r.Address, sibling.Address)
+				a.setInconclusive(comp.Created, comp.Destroyed, reason)
+			}
+		}
+	}
+}
+
+// setInconclusive marks the comparison between two resources as inconclusive,
+// in the comparisons of both resources.
+func (a *Analysis) setInconclusive(created, destroyed *Resource, reason string) {
+	for _, r := range []*Resource{created, destroyed} {
+		for i := range a.Comparisons[r] {
+			comp := &a.Comparisons[r][i]
+			if comp.Created == created && comp.Destroyed == destroyed {
+				comp.Inconclusive = reason
+			}
+		}
+	}
+}
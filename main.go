@@ -3,14 +3,22 @@ package main
 import (
 	"context"
 	_ "embed"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/terraform-exec/tfexec"
+	tfjson "github.com/hashicorp/terraform-json"
 
+	"github.com/busser/tfautomv/internal/config"
 	"github.com/busser/tfautomv/internal/format"
+	"github.com/busser/tfautomv/internal/pipeline"
 	"github.com/busser/tfautomv/internal/terraform"
 	"github.com/busser/tfautomv/internal/tfautomv"
 	"github.com/busser/tfautomv/internal/tfautomv/ignore"
@@ -32,41 +40,139 @@ func run() error {
 	if noColor {
 		format.NoColor = true
 	}
+	if showSensitive {
+		format.ShowSensitive = true
+	}
+	if groupByModule {
+		format.GroupByModule = true
+	}
+	if configuredOnly {
+		format.ConfiguredOnly = true
+	}
 
 	if printVersion {
 		fmt.Println(tfautomvVersion)
 		return nil
 	}
 
-	tf, err := tfexec.NewTerraform(".", terraformBin)
+	// Settings from a configuration file apply unless overridden by flags.
+	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
 
+	if err := checkPlanFlags(); err != nil {
+		return err
+	}
+
 	ctx := context.TODO()
 
+	// When given a plan in JSON format, tfautomv does not need to run Terraform
+	// at all.
+
+	var tf *tfexec.Terraform
+	if planJSON == "" {
+		tf, err = tfexec.NewTerraform(".", terraformBin)
+		if err != nil {
+			return err
+		}
+	}
+
+	var existingPlan *tfjson.Plan
+	switch {
+	case planJSON != "":
+		p, err := readPlanJSON(planJSON)
+		if err != nil {
+			return err
+		}
+		existingPlan = p
+	case planFile != "":
+		logln(fmt.Sprintf("Running \"terraform show %s\"...", planFile))
+		p, err := tf.ShowPlanFile(ctx, planFile)
+		if err != nil {
+			return err
+		}
+		existingPlan = p
+	}
+
 	// Some Terraform versions do not support some of tfautomv's output options.
 	// Check that everything is OK early on, to avoid wasting time running a
 	// plan for nothing.
 
-	tfVer, _, err := tf.Version(ctx, false)
-	if err != nil {
-		return err
+	var tfVer *version.Version
+	switch {
+	case existingPlan != nil && existingPlan.TerraformVersion != "":
+		v, err := version.NewVersion(existingPlan.TerraformVersion)
+		if err != nil {
+			return fmt.Errorf("invalid terraform version in plan: %w", err)
+		}
+		tfVer = v
+	case tf != nil:
+		v, _, err := tf.Version(ctx, false)
+		if err != nil {
+			return err
+		}
+		tfVer = v
+	default:
+		// Without running Terraform, there is no way to know which version
+		// made a plan that does not say. We assume it supports everything.
+		fmt.Fprint(os.Stderr, format.Warning("The plan does not say which version of Terraform made it, so tfautomv cannot check that the version supports the chosen output."))
 	}
 
 	switch outputFormat {
 	case "blocks":
-		if tfVer.LessThan(version.Must(version.NewVersion("1.1"))) {
+		if !supports(tfVer, "1.1") {
 			return fmt.Errorf("terraform version %s does not support moved blocks", tfVer.String())
 		}
 	case "commands":
+	case "imports":
+		if !supports(tfVer, "1.5") {
+			return fmt.Errorf("terraform version %s does not support import blocks", tfVer.String())
+		}
+		// Import blocks use the ID of each resource, which is only known
+		// for individual resource instances.
+		if collapseMoves {
+			return errors.New("the -collapse flag cannot be used with -output=imports")
+		}
+	case "json":
+	case "tfmigrate":
 	default:
 		return fmt.Errorf("unknown output format %q", outputFormat)
 	}
 
+	switch outputPlacement {
+	case "file", "colocated":
+	default:
+		return fmt.Errorf("unknown output placement %q", outputPlacement)
+	}
+
+	if verifyRollback && !verify {
+		return errors.New("the -verify-rollback flag requires the -verify flag")
+	}
+	if verify {
+		switch {
+		case outputFormat != "blocks":
+			return errors.New("the -verify flag only works with moved blocks")
+		case dryRun:
+			return errors.New("the -verify and -dry-run flags are mutually exclusive")
+		}
+	}
+
+	if minScore < 0 || minScore > 1 {
+		return fmt.Errorf("invalid -min-score %v: must be between 0 and 1", minScore)
+	}
+
+	opts, err := analysisOptions(tfVer)
+	if err != nil {
+		return err
+	}
+
 	// Parse rules early on so that the user gets quick feedback in case of
 	// syntax errors.
 	var rules []ignore.Rule
+	if cfg != nil {
+		rules = append(rules, cfg.Rules...)
+	}
 	for _, raw := range ignoreRules {
 		r, err := ignore.ParseRule(raw)
 		if err != nil {
@@ -75,95 +181,424 @@ func run() error {
 		rules = append(rules, r)
 	}
 
+	var pairings []tfautomv.Pairing
+	if pairingsFile != "" {
+		p, err := readPairings(pairingsFile)
+		if err != nil {
+			return err
+		}
+		pairings = p
+	}
+
+	find := pipeline.Options{
+		Inspect:  inspector(),
+		Rules:    rules,
+		Analysis: opts,
+		Moves: tfautomv.MovesOptions{
+			OptimalAssignment: optimalAssignment,
+			MinScore:          minScore,
+		},
+		Pairings: pairings,
+		Collapse: collapseMoves,
+	}
+
+	if len(workdirs) > 0 {
+		return runCrossState(ctx, find)
+	}
+
 	// Terraform's plan contains a lot of information. For now, this is all we
 	// need. In the future, we may choose to use other sources of information.
 
-	logln("Running \"terraform init\"...")
-	err = tf.Init(ctx)
+	if existingPlan == nil {
+		logln("Running \"terraform init\"...")
+		if err := tf.Init(ctx); err != nil {
+			return err
+		}
+	}
+
+	// The plan only names the provider of each destroyed resource, not its
+	// alias. The state records which provider configuration manages each
+	// resource. Reading it requires access to the backend, which users who
+	// provide an existing plan may not have, so we only read it when we run
+	// the plan ourselves.
+
+	if existingPlan == nil {
+		providers, err := stateProviders(ctx, tf)
+		if err != nil {
+			return err
+		}
+		find.Analysis.StateProviders = map[string]map[string]string{"": providers}
+	} else if several := tfautomv.ProvidersWithSeveralConfigs(existingPlan); len(several) > 0 && !allowCrossProvider {
+		fmt.Fprint(os.Stderr, format.Warning(fmt.Sprintf("Your configuration declares several configurations of %s. Without reading the state, tfautomv cannot tell which of them manages resources planned for destruction, so it may pair resources managed by different configurations. Review those moves carefully.", strings.Join(several, ", "))))
+	}
+
+	find.Plan = func(pass int) (map[string]*tfjson.Plan, error) {
+		plan := existingPlan
+		if plan == nil {
+			if pass == 1 {
+				logln("Running \"terraform plan\"...")
+			} else {
+				logln(fmt.Sprintf("Running \"terraform plan\" again (pass %d)...", pass))
+			}
+			p, err := terraformPlan(ctx, tf)
+			if err != nil {
+				return nil, err
+			}
+			plan = p
+		}
+		return map[string]*tfjson.Plan{"": plan}, nil
+	}
+
+	// When writing moved blocks to disk, we can run multiple passes, to find
+	// moves that depend on other moves. This is not possible when the user
+	// provides an existing plan.
+
+	multiPass := outputFormat == "blocks" && !dryRun && existingPlan == nil
+	if multiPass {
+		find.Write = writeMovedBlocks
+	}
+
+	res, err := pipeline.FindMoves(find)
 	if err != nil {
 		return err
 	}
 
-	logln("Running \"terraform plan\"...")
-	planFile, err := os.CreateTemp("", "tfautomv.*.plan")
+	return output(res, map[string]func(*pipeline.Result) error{
+		"blocks": func(res *pipeline.Result) error {
+			if multiPass {
+				// Moved blocks were written to disk during each pass.
+				fmt.Fprint(os.Stderr, format.Done(fmt.Sprintf("Added %d moved blocks to %s in %d passes.", res.Written, movedBlocksDestination(), res.Passes)))
+			} else {
+				n, err := writeMovedBlocks(res.Moves)
+				if err != nil {
+					return err
+				}
+				fmt.Fprint(os.Stderr, format.Done(fmt.Sprintf("Added %d moved blocks to %s.", n, movedBlocksDestination())))
+			}
+
+			if !verify {
+				return nil
+			}
+
+			// With multiple passes, the last plan already includes every
+			// moved block we wrote.
+			plan := res.Plans[""]
+			if !multiPass {
+				logln("Running \"terraform plan\" to verify moves...")
+				p, err := terraformPlan(ctx, tf)
+				if err != nil {
+					return err
+				}
+				plan = p
+			}
+			return verifyPlan(plan)
+		},
+		"commands": func(res *pipeline.Result) error {
+			terraform.WriteMovesShellCommands(res.Moves, os.Stdout)
+			fmt.Fprint(os.Stderr, format.Done(fmt.Sprintf("Wrote %d commands to standard output.", len(res.Moves))))
+			return nil
+		},
+		"imports": func(res *pipeline.Result) error {
+			return writeImportBlocks(res.Analysis, res.Moves, res.Plans[""], tfVer)
+		},
+		"tfmigrate": func(res *pipeline.Result) error {
+			path, err := writeTfmigrateMigration(res.Moves)
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(os.Stderr, format.Done(fmt.Sprintf("Wrote a tfmigrate migration with %d moves to %q.", len(res.Moves), path)))
+			return nil
+		},
+	})
+}
+
+// output reports the moves found, in the format chosen with the -output flag.
+// writers write moves in each format other than json.
+func output(res *pipeline.Result, writers map[string]func(*pipeline.Result) error) error {
+	return pipeline.Output(res, pipeline.OutputOptions{
+		Format:  outputFormat,
+		DryRun:  dryRun,
+		Writers: writers,
+		Stdout:  os.Stdout,
+		Stderr:  os.Stderr,
+	})
+}
+
+// loadConfig reads the configuration file closest to the working directory,
+// if any, and applies its settings to all flags the user did not set
+// explicitly.
+func loadConfig() (*config.Config, error) {
+	path, err := config.Find(".")
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer os.Remove(planFile.Name())
-	if _, err := tf.Plan(context.TODO(), tfexec.Out(planFile.Name())); err != nil {
-		return err
+	if path == "" {
+		return nil, nil
 	}
-	plan, err := tf.ShowPlanFile(context.TODO(), planFile.Name())
+
+	cfg, err := config.Load(path)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("invalid configuration file: %w", err)
+	}
+
+	setByUser := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		setByUser[f.Name] = true
+	})
+
+	settings := []struct {
+		flag  string
+		value string
+		dst   *string
+	}{
+		{"output", cfg.Output, &outputFormat},
+		{"output-file", cfg.OutputFile, &outputFile},
+		{"terraform-bin", cfg.TerraformBin, &terraformBin},
+	}
+	for _, s := range settings {
+		if s.value != "" && !setByUser[s.flag] {
+			*s.dst = s.value
+		}
 	}
 
-	analysis, err := tfautomv.AnalysisFromPlan(plan, rules)
+	return cfg, nil
+}
+
+// stateProviders reads the provider configuration of each resource in the
+// working directory's state.
+func stateProviders(ctx context.Context, tf *tfexec.Terraform) (map[string]string, error) {
+	raw, err := tf.StatePull(ctx)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to read state: %w", err)
 	}
-	if showAnalysis {
-		fmt.Fprint(os.Stderr, format.Analysis(analysis))
+	return terraform.StateProviders(raw)
+}
+
+// terraformPlan runs a Terraform plan and returns its contents.
+func terraformPlan(ctx context.Context, tf *tfexec.Terraform) (*tfjson.Plan, error) {
+	planFile, err := os.CreateTemp("", "tfautomv.*.plan")
+	if err != nil {
+		return nil, err
 	}
+	defer os.Remove(planFile.Name())
 
-	moves := tfautomv.MovesFromAnalysis(analysis)
-	if len(moves) == 0 {
-		fmt.Fprint(os.Stderr, format.Done("Found no moves to make"))
-		return nil
+	if _, err := tf.Plan(ctx, tfexec.Out(planFile.Name())); err != nil {
+		return nil, err
 	}
 
-	// At this point, we need to output the moves we found. The Terraform
-	// community originally used `tf state mv` commands. Terraform 1.1+ supports
-	// moved blocks as a replacement, but those remain incomplete for now.
-	// Community tools like tfmigrate are also popular.
+	return tf.ShowPlanFile(ctx, planFile.Name())
+}
+
+// analysisOptions returns the options for comparing resources, based on flags.
+func analysisOptions(tfVer *version.Version) (tfautomv.AnalysisOptions, error) {
+	opts := tfautomv.AnalysisOptions{
+		AllowCrossProvider: allowCrossProvider,
+		ConfiguredOnly:     configuredOnly,
+		StrictUnknowns:     strictUnknowns,
+		MinMatchingByType:  make(map[string]tfautomv.Threshold),
+	}
 
-	if dryRun {
-		fmt.Fprint(os.Stderr, format.Moves(moves))
+	// Thresholds can be set for all resources or for a single type, with
+	// values like "2" or "random_pet=2".
+	update := func(raw string, f func(*tfautomv.Threshold, string) error) error {
+		typ, value, ok := strings.Cut(raw, "=")
+		if !ok {
+			return f(&opts.MinMatching, raw)
+		}
+		t := opts.MinMatchingByType[typ]
+		if err := f(&t, value); err != nil {
+			return err
+		}
+		opts.MinMatchingByType[typ] = t
 		return nil
 	}
 
-	switch outputFormat {
-	case "blocks":
-		err = terraform.AppendMovesToFile(moves, "moves.tf")
+	for _, raw := range minMatching {
+		err := update(raw, func(t *tfautomv.Threshold, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid -min-matching %q: must be a non-negative integer", raw)
+			}
+			t.Count = n
+			t.CountSet = true
+			return nil
+		})
 		if err != nil {
-			return err
+			return opts, err
 		}
-		fmt.Fprint(os.Stderr, format.Done(fmt.Sprintf("Added %d moved blocks to \"moves.tf\".", len(moves))))
+	}
 
-	case "commands":
-		terraform.WriteMovesShellCommands(moves, os.Stdout)
-		fmt.Fprint(os.Stderr, format.Done(fmt.Sprintf("Wrote %d commands to standard output.", len(moves))))
+	for _, raw := range minMatchingRatio {
+		err := update(raw, func(t *tfautomv.Threshold, value string) error {
+			r, err := strconv.ParseFloat(value, 64)
+			if err != nil || r < 0 || r > 1 {
+				return fmt.Errorf("invalid -min-matching-ratio %q: must be between 0 and 1", raw)
+			}
+			t.Ratio = r
+			t.RatioSet = true
+			return nil
+		})
+		if err != nil {
+			return opts, err
+		}
+	}
 
-	default:
-		return fmt.Errorf("unknown output format %q", outputFormat)
+	// Moves between resources of different types are only possible with
+	// moved blocks, since Terraform 1.8. Built-in equivalences are used
+	// whenever the Terraform version supports them, but the user's
+	// equivalences must be usable.
+	crossType := outputFormat == "json" ||
+		(outputFormat == "blocks" && supports(tfVer, "1.8"))
+	if crossType {
+		opts.TypeEquivalences = append(opts.TypeEquivalences, tfautomv.BuiltinTypeEquivalences(tfVer)...)
+	}
+	for _, raw := range typeEquivalences {
+		switch {
+		case crossType:
+		case outputFormat != "blocks":
+			return opts, errors.New("the -type-equivalence flag requires moved blocks")
+		default:
+			return opts, fmt.Errorf("the -type-equivalence flag requires moved blocks, which only support moves between types since Terraform 1.8 (found %s)", tfVer.String())
+		}
+		eq, err := tfautomv.ParseTypeEquivalence(raw)
+		if err != nil {
+			return opts, fmt.Errorf("invalid -type-equivalence %q: %w", raw, err)
+		}
+		opts.TypeEquivalences = append(opts.TypeEquivalences, eq)
+	}
+
+	return opts, nil
+}
+
+// readPairings reads the pairings file at path.
+func readPairings(path string) ([]tfautomv.Pairing, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
 	}
+	defer f.Close()
 
+	pairings, err := tfautomv.ParsePairings(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pairings file %q: %w", path, err)
+	}
+
+	return pairings, nil
+}
+
+// checkPlanFlags checks that the flags providing an existing plan are not
+// combined with each other or with flags they are incompatible with.
+func checkPlanFlags() error {
+	switch {
+	case planFile != "" && planJSON != "":
+		return errors.New("the -plan-file and -plan-json flags are mutually exclusive")
+	case interactive && planJSON == "-":
+		return errors.New("the -interactive flag reads choices from standard input, so the plan cannot be read from it")
+	case verify && planJSON != "":
+		return errors.New("the -verify flag requires running terraform, so it cannot be used with -plan-json")
+	case len(workdirs) > 0 && (planFile != "" || planJSON != ""):
+		return errors.New("the -dir flag cannot be used with -plan-file or -plan-json")
+	}
 	return nil
 }
 
+// supports returns whether Terraform version tfVer is at least min. When the
+// version is unknown, it is assumed to be recent enough.
+func supports(tfVer *version.Version, min string) bool {
+	return tfVer == nil || !tfVer.LessThan(version.Must(version.NewVersion(min)))
+}
+
+// readPlanJSON reads a plan in the JSON format produced by the
+// `terraform show -json` command. If path is "-", the plan is read from
+// standard input.
+func readPlanJSON(path string) (*tfjson.Plan, error) {
+	var r io.Reader
+	if path == "-" {
+		r = os.Stdin
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var plan tfjson.Plan
+	if err := json.NewDecoder(r).Decode(&plan); err != nil {
+		return nil, fmt.Errorf("could not read plan from %q: %w", path, err)
+	}
+
+	return &plan, nil
+}
+
 func logln(msg string) {
 	fmt.Fprint(os.Stderr, format.Info(msg))
 }
 
 // Flags
 var (
-	dryRun       bool
-	ignoreRules  []string
-	noColor      bool
-	outputFormat string
-	printVersion bool
-	showAnalysis bool
-	terraformBin string
+	allowCrossProvider bool
+	collapseMoves      bool
+	configuredOnly     bool
+	dryRun             bool
+	groupByModule      bool
+	ignoreRules        []string
+	interactive        bool
+	minMatching        []string
+	minMatchingRatio   []string
+	minScore           float64
+	noColor            bool
+	optimalAssignment  bool
+	outputFile         string
+	outputFormat       string
+	outputPlacement    string
+	pairingsFile       string
+	planFile           string
+	planJSON           string
+	printVersion       bool
+	showAnalysis       bool
+	showSensitive      bool
+	strictUnknowns     bool
+	terraformBin       string
+	tfmigrateDir       string
+	tfmigrateFile      string
+	typeEquivalences   []string
+	verify             bool
+	verifyRollback     bool
+	workdirs           []string
 )
 
 func parseFlags() {
+	flag.BoolVar(&allowCrossProvider, "allow-cross-provider", false, "allow pairing resources managed by different providers")
+	flag.BoolVar(&collapseMoves, "collapse", false, "move entire modules and resources instead of each of their instances when possible")
+	flag.BoolVar(&configuredOnly, "configured-only", false, "only compare attributes set in the Terraform configuration, ignoring those computed by providers")
+	flag.Var(stringSliceValue{&workdirs}, "dir", "`path` to a working directory to find moves between; repeat to compare several states")
 	flag.BoolVar(&dryRun, "dry-run", false, "print moves instead of writing them to disk")
+	flag.BoolVar(&groupByModule, "group-by-module", false, "group resources by module in the analysis")
 	flag.Var(stringSliceValue{&ignoreRules}, "ignore", "ignore differences based on a `rule`")
+	flag.BoolVar(&interactive, "interactive", false, "ask which resources to pair when a resource has several matches")
+	flag.Var(stringSliceValue{&minMatching}, "min-matching", "lowest `count` of attributes that must match, optionally for one type (\"TYPE=COUNT\"); repeatable")
+	flag.Var(stringSliceValue{&minMatchingRatio}, "min-matching-ratio", "lowest `ratio` of compared attributes that must match, optionally for one type (\"TYPE=RATIO\"); repeatable")
+	flag.Float64Var(&minScore, "min-score", 0.8, "lowest `score` two resources can have to be paired by -optimal-assignment")
 	flag.BoolVar(&noColor, "no-color", false, "disable color in output")
-	flag.StringVar(&outputFormat, "output", "blocks", "output `format` of moves (\"blocks\" or \"commands\")")
+	flag.BoolVar(&optimalAssignment, "optimal-assignment", false, "pair resources with multiple matches so that they are as similar as possible")
+	flag.StringVar(&outputFile, "output-file", "moves.tf", "`path` to the file moved blocks are written to")
+	flag.StringVar(&outputFormat, "output", "blocks", "output `format` of moves (\"blocks\", \"commands\", \"imports\", \"json\", or \"tfmigrate\")")
+	flag.StringVar(&outputPlacement, "output-placement", "file", "where to write moved blocks (\"file\" or \"colocated\")")
+	flag.StringVar(&pairingsFile, "pairings", "", "`path` to a file of pairings to use instead of matching those resources automatically")
+	flag.StringVar(&planFile, "plan-file", "", "use an existing plan `file` instead of running terraform plan")
+	flag.StringVar(&planJSON, "plan-json", "", "use an existing plan in JSON format from `file` instead of running terraform (\"-\" reads from standard input)")
 	flag.BoolVar(&showAnalysis, "show-analysis", false, "show detailed analysis of Terraform plan")
+	flag.BoolVar(&showSensitive, "show-sensitive", false, "show the values of sensitive attributes in output")
+	flag.BoolVar(&strictUnknowns, "strict-unknowns", false, "do not pair resources with more attributes known only after apply than matching attributes")
 	flag.BoolVar(&printVersion, "version", false, "print version and exit")
 	flag.StringVar(&terraformBin, "terraform-bin", "terraform", "terraform binary to use")
+	flag.StringVar(&tfmigrateDir, "tfmigrate-dir", "tfmigrate", "`path` to the directory tfmigrate migration files are written to")
+	flag.StringVar(&tfmigrateFile, "tfmigrate-file", "tfautomv.hcl", "`name` of the tfmigrate migration file")
+	flag.Var(stringSliceValue{&typeEquivalences}, "type-equivalence", "allow moves between resource types, as \"FROM:TO\" or \"FROM:TO:ATTR=ATTR,...\"; repeatable")
+	flag.BoolVar(&verify, "verify", false, "run terraform plan after writing moved blocks and fail if it plans any changes")
+	flag.BoolVar(&verifyRollback, "verify-rollback", false, "remove the moved blocks tfautomv wrote if -verify fails")
 
 	flag.Parse()
 }
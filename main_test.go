@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+
+	"github.com/busser/tfautomv/internal/tfautomv"
+)
+
+func TestReadPlanJSON(t *testing.T) {
+	tt := []struct {
+		name        string
+		content     string
+		stdin       bool
+		wantVersion string
+		wantErr     bool
+	}{
+		{
+			name:        "valid plan",
+			content:     `{"format_version": "1.2", "terraform_version": "1.6.0"}`,
+			wantVersion: "1.6.0",
+		},
+		{
+			name:        "valid plan from standard input",
+			content:     `{"format_version": "1.2", "terraform_version": "1.6.0"}`,
+			stdin:       true,
+			wantVersion: "1.6.0",
+		},
+		{
+			name:        "plan without version",
+			content:     `{"format_version": "1.2"}`,
+			wantVersion: "",
+		},
+		{
+			name:    "invalid JSON",
+			content: `{"format_version": `,
+			wantErr: true,
+		},
+		{
+			name:    "unsupported format version",
+			content: `{"format_version": "2.0"}`,
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "plan.json")
+			if tc.content != "" {
+				if err := os.WriteFile(path, []byte(tc.content), 0o644); err != nil {
+					t.Fatalf("failed to write plan: %v", err)
+				}
+			}
+
+			if tc.stdin {
+				f, err := os.Open(path)
+				if err != nil {
+					t.Fatalf("failed to open plan: %v", err)
+				}
+				defer f.Close()
+
+				original := os.Stdin
+				os.Stdin = f
+				defer func() { os.Stdin = original }()
+
+				path = "-"
+			}
+
+			plan, err := readPlanJSON(path)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("readPlanJSON() should have returned an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readPlanJSON() returned error: %v", err)
+			}
+
+			if plan.TerraformVersion != tc.wantVersion {
+				t.Errorf("TerraformVersion = %q, want %q", plan.TerraformVersion, tc.wantVersion)
+			}
+		})
+	}
+}
+
+func TestCheckPlanFlags(t *testing.T) {
+	tt := []struct {
+		name        string
+		planFile    string
+		planJSON    string
+		interactive bool
+		verify      bool
+		workdirs    []string
+		wantErr     bool
+	}{
+		{
+			name: "no plan",
+		},
+		{
+			name:     "plan file",
+			planFile: "tfplan",
+		},
+		{
+			name:     "plan in JSON",
+			planJSON: "tfplan.json",
+		},
+		{
+			name:     "plan file and plan in JSON",
+			planFile: "tfplan",
+			planJSON: "tfplan.json",
+			wantErr:  true,
+		},
+		{
+			name:        "interactive with plan in JSON from a file",
+			planJSON:    "tfplan.json",
+			interactive: true,
+		},
+		{
+			name:        "interactive with plan in JSON from standard input",
+			planJSON:    "-",
+			interactive: true,
+			wantErr:     true,
+		},
+		{
+			name:     "verify with plan file",
+			planFile: "tfplan",
+			verify:   true,
+		},
+		{
+			name:     "verify with plan in JSON",
+			planJSON: "tfplan.json",
+			verify:   true,
+			wantErr:  true,
+		},
+		{
+			name:     "several working directories",
+			workdirs: []string{"a", "b"},
+		},
+		{
+			name:     "several working directories with plan file",
+			planFile: "tfplan",
+			workdirs: []string{"a", "b"},
+			wantErr:  true,
+		},
+		{
+			name:     "several working directories with plan in JSON",
+			planJSON: "tfplan.json",
+			workdirs: []string{"a", "b"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func(f, j string, i, v bool, w []string) {
+				planFile, planJSON, interactive, verify, workdirs = f, j, i, v, w
+			}(planFile, planJSON, interactive, verify, workdirs)
+
+			planFile = tc.planFile
+			planJSON = tc.planJSON
+			interactive = tc.interactive
+			verify = tc.verify
+			workdirs = tc.workdirs
+
+			err := checkPlanFlags()
+			if tc.wantErr && err == nil {
+				t.Errorf("checkPlanFlags() should have returned an error")
+			}
+			if !tc.wantErr && err != nil {
+				t.Errorf("checkPlanFlags() returned error: %v", err)
+			}
+		})
+	}
+}
+
+func TestResolveAmbiguitiesAcrossPasses(t *testing.T) {
+	var plan tfjson.Plan
+	for _, addr := range []string{"random_pet.alpha", "random_pet.beta"} {
+		plan.ResourceChanges = append(plan.ResourceChanges, &tfjson.ResourceChange{
+			Address: addr,
+			Type:    "random_pet",
+			Change: &tfjson.Change{
+				Actions: []tfjson.Action{tfjson.ActionCreate},
+				After:   map[string]interface{}{"length": 2},
+			},
+		})
+	}
+	for _, addr := range []string{"random_pet.first", "random_pet.second"} {
+		plan.ResourceChanges = append(plan.ResourceChanges, &tfjson.ResourceChange{
+			Address: addr,
+			Type:    "random_pet",
+			Change: &tfjson.Change{
+				Actions: []tfjson.Action{tfjson.ActionDelete},
+				Before:  map[string]interface{}{"length": 2},
+			},
+		})
+	}
+
+	defer func(r *bufio.Reader) { stdin = r }(stdin)
+	skipped := make(map[ambiguityKey]bool)
+
+	// The first pass asks about each of the four ambiguous resources, and
+	// the user skips them all. Later passes analyse a new plan, so their
+	// resources are new, but they must not be asked about again: with no
+	// input left, any prompt would fail.
+	for i, input := range []string{"\n\n\n\n", ""} {
+		analysis, err := tfautomv.AnalysisFromPlan(&plan, nil, tfautomv.AnalysisOptions{})
+		if err != nil {
+			t.Fatalf("AnalysisFromPlan() returned error: %v", err)
+		}
+
+		stdin = bufio.NewReader(strings.NewReader(input))
+		if err := resolveAmbiguities(analysis, skipped); err != nil {
+			t.Fatalf("resolveAmbiguities() on pass %d returned error: %v", i+1, err)
+		}
+
+		if n := len(analysis.Ambiguities()); n != 4 {
+			t.Errorf("%d ambiguities remain after pass %d, want 4", n, i+1)
+		}
+	}
+}
+
+func TestAnalysisOptionsMinMatching(t *testing.T) {
+	defer func(m, r []string) {
+		minMatching, minMatchingRatio = m, r
+	}(minMatching, minMatchingRatio)
+
+	minMatching = []string{"2", "random_pet=0"}
+	minMatchingRatio = []string{"0.5", "random_pet=0"}
+
+	opts, err := analysisOptions(nil)
+	if err != nil {
+		t.Fatalf("analysisOptions() returned error: %v", err)
+	}
+
+	comp := tfautomv.Compare(
+		&tfautomv.Resource{Type: "random_pet", Attributes: map[string]interface{}{}},
+		&tfautomv.Resource{Type: "random_pet", Attributes: map[string]interface{}{}},
+		nil, opts,
+	)
+	if comp.Inconclusive != "" {
+		t.Errorf("comparison is inconclusive: %s", comp.Inconclusive)
+	}
+}
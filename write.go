@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	tfjson "github.com/hashicorp/terraform-json"
+
+	"github.com/busser/tfautomv/internal/format"
+	"github.com/busser/tfautomv/internal/terraform"
+	"github.com/busser/tfautomv/internal/tfautomv"
+)
+
+// writeTfmigrateMigration writes a tfmigrate migration file in -tfmigrate-dir
+// and returns its path. Existing migrations are never overwritten, since they
+// may already have been applied.
+func writeTfmigrateMigration(moves []terraform.Move) (string, error) {
+	if err := os.MkdirAll(tfmigrateDir, 0755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(tfmigrateDir, tfmigrateFile)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return "", fmt.Errorf("migration file %q already exists", path)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	name := strings.TrimSuffix(tfmigrateFile, filepath.Ext(tfmigrateFile))
+	terraform.WriteTfmigrateMigration(moves, name, f)
+
+	return path, nil
+}
+
+// writeImportBlocks writes an import block for the destination of each move,
+// using the ID of the resource at the move's source. Importing a resource
+// does not remove it from its previous address, where Terraform would destroy
+// it, so a removed block is also written for each source when Terraform
+// supports it.
+func writeImportBlocks(analysis *tfautomv.Analysis, moves []terraform.Move, plan *tfjson.Plan, tfVer *version.Version) error {
+	imports, err := tfautomv.ImportsFromMoves(analysis, moves)
+	if err != nil {
+		return err
+	}
+	for _, w := range terraform.ImportIDWarnings(imports) {
+		fmt.Fprint(os.Stderr, format.Warning(w))
+	}
+
+	var removals []terraform.Removal
+	if !supports(tfVer, "1.7") {
+		var b strings.Builder
+		fmt.Fprintf(&b, "Terraform %s does not support removed blocks. Before applying, remove the original resources from the state with:\n", tfVer.String())
+		for _, m := range moves {
+			fmt.Fprintf(&b, "\n  terraform state rm %q", m.From)
+		}
+		fmt.Fprint(os.Stderr, format.Warning(b.String()))
+	} else {
+		inState := map[string][]string{"": terraform.StateAddresses(plan.PriorState)}
+		created := map[string][]string{"": terraform.CreatedAddresses(plan)}
+		removals, err = terraform.RemovalsFromMoves(moves, inState, created)
+		if err != nil {
+			return fmt.Errorf("%w; use -output=commands instead", err)
+		}
+	}
+
+	if err := terraform.AppendRemovalsToFile(removals, outputFile); err != nil {
+		return err
+	}
+	if err := terraform.AppendImportsToFile(imports, outputFile); err != nil {
+		return err
+	}
+
+	fmt.Fprint(os.Stderr, format.Done(fmt.Sprintf("Added %d import blocks and %d removed blocks to %q.", len(imports), len(removals), outputFile)))
+
+	return nil
+}
+
+// verifyPlan checks that Terraform plans no changes now that moved blocks were
+// written. Otherwise, it reports the remaining changes and returns an error,
+// after removing the moved blocks if the user asked to.
+func verifyPlan(plan *tfjson.Plan) error {
+	changes, err := terraform.PlannedChanges(plan)
+	if err != nil {
+		return err
+	}
+
+	if len(changes) == 0 {
+		fmt.Fprint(os.Stderr, format.Done("Verified that Terraform plans no changes."))
+		return nil
+	}
+
+	fmt.Fprint(os.Stderr, format.Verification(changes))
+
+	if verifyRollback {
+		if err := restoreFiles(); err != nil {
+			return fmt.Errorf("could not remove moved blocks: %w", err)
+		}
+		logln("Removed the moved blocks written by tfautomv.")
+	}
+
+	return fmt.Errorf("verification failed: Terraform still plans changes to %d resources", len(changes))
+}
+
+// originalFiles holds the contents of each file tfautomv modified, as they
+// were before the first modification, so that all changes can be undone. A
+// nil value means the file did not exist.
+var originalFiles = make(map[string][]byte)
+
+// backupFile saves the contents of a file before tfautomv modifies it.
+func backupFile(path string) error {
+	if _, ok := originalFiles[path]; ok {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		originalFiles[path] = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	originalFiles[path] = data
+	return nil
+}
+
+// restoreFiles undoes all modifications tfautomv made to files.
+func restoreFiles() error {
+	for path, data := range originalFiles {
+		var err error
+		if data == nil {
+			err = os.Remove(path)
+		} else {
+			err = os.WriteFile(path, data, 0644)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeMovedBlocks writes moved blocks to disk, where the -output-placement
+// flag says they belong. Moves already declared by moved blocks in the code
+// are skipped. It returns how many blocks were added.
+func writeMovedBlocks(moves []terraform.Move) (int, error) {
+	blocks, err := terraform.LoadMovedBlocks(".")
+	if err != nil {
+		return 0, err
+	}
+
+	remaining, err := terraform.ReconcileMoves(moves, blocks)
+	if err != nil {
+		return 0, err
+	}
+
+	if skipped := len(moves) - len(remaining); skipped > 0 {
+		logln(fmt.Sprintf("Skipped %d moves already declared by moved blocks.", skipped))
+	}
+
+	switch {
+	case len(remaining) == 0:
+	case outputPlacement == "colocated":
+		files, err := terraform.ColocateMoves(remaining, ".")
+		if err != nil {
+			return 0, err
+		}
+		for path := range files {
+			if err := backupFile(path); err != nil {
+				return 0, err
+			}
+		}
+		err = terraform.AppendMovesToFiles(files)
+		if err != nil {
+			return 0, err
+		}
+	default:
+		if err := backupFile(outputFile); err != nil {
+			return 0, err
+		}
+		err = terraform.AppendMovesToFile(remaining, outputFile)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(remaining), nil
+}
+
+// movedBlocksDestination describes where writeMovedBlocks writes moved blocks,
+// for use in messages to the user.
+func movedBlocksDestination() string {
+	if outputPlacement == "colocated" {
+		return "the files declaring their targets"
+	}
+	return fmt.Sprintf("%q", outputFile)
+}